@@ -0,0 +1,184 @@
+// cmd/api/dlq.go
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/usecase/dlq"
+)
+
+const dlqUsage = `usage: api dlq list [-limit N] [filters]
+       api dlq summary [filters]
+       api dlq show ID
+       api dlq replay [-only-failed] [filters] (ID... | -all)
+       api dlq ignore [-note TEXT] [filters] (ID... | -all)
+       api dlq reopen [-note TEXT] [filters] (ID... | -all)
+       api dlq purge [filters] (ID... | -all)
+       api dlq backfill
+filters: -product ID -class CLASS -status open|replayed|ignored -error TEXT
+         -since RFC3339 -until RFC3339`
+
+// maxErrorWidth truncates errors in listings; show prints them in full
+const maxErrorWidth = 80
+
+// runDLQ handles the `dlq` subcommand.
+func runDLQ(u dlq.Usecase, args []string) error {
+	if len(args) == 0 {
+		return errors.New(dlqUsage)
+	}
+
+	flags := flag.NewFlagSet("dlq "+args[0], flag.ContinueOnError)
+	productID := flags.String("product", "", "only entries of this product")
+	errorClass := flags.String("class", "", "only entries of this error class")
+	status := flags.String("status", "", "only entries with this status")
+	errorText := flags.String("error", "", "only entries whose error contains this text")
+	since := flags.String("since", "", "only entries failed at or after this time")
+	until := flags.String("until", "", "only entries failed before this time")
+	limit := flags.Int("limit", 100, "list at most this many entries, 0 for all")
+	all := flags.Bool("all", false, "select every entry matching the filters")
+	onlyFailed := flags.Bool("only-failed", false, "reprocess only the images that were not uploaded")
+	note := flags.String("note", "", "resolution note")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errors.New(dlqUsage)
+	}
+
+	filter := model.DLQFilter{
+		ProductID:  *productID,
+		ErrorClass: model.ErrorClass(*errorClass),
+		Status:     model.DLQStatus(*status),
+		Error:      *errorText,
+	}
+	for _, bound := range []struct {
+		value  string
+		target *time.Time
+	}{{*since, &filter.Since}, {*until, &filter.Until}} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return fmt.Errorf("invalid time %q: %w", bound.value, err)
+		}
+		*bound.target = t
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "list":
+		filter.Limit = *limit
+		entries, err := u.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		return printDLQEntries(entries)
+	case "summary":
+		summary, err := u.Summarize(ctx, filter)
+		if err != nil {
+			return err
+		}
+		return printDLQSummary(summary)
+	case "show":
+		if flags.NArg() != 1 {
+			return errors.New(dlqUsage)
+		}
+		entry, err := u.Get(ctx, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entry)
+	case "replay":
+		result, err := u.Replay(ctx, model.DLQReplayRequest{
+			IDs:        flags.Args(),
+			All:        *all,
+			Filter:     filter,
+			OnlyFailed: *onlyFailed,
+		})
+		if result != nil {
+			fmt.Printf("replayed %d: %s\n", len(result.Replayed), strings.Join(result.Replayed, " "))
+			fmt.Printf("skipped %d, resolved before: %s\n", len(result.Skipped), strings.Join(result.Skipped, " "))
+		}
+		return err
+	case "ignore", "reopen":
+		resolution := model.DLQStatusIgnored
+		if args[0] == "reopen" {
+			resolution = model.DLQStatusOpen
+		}
+		resolved, err := u.Resolve(ctx, model.DLQResolveRequest{
+			IDs:        flags.Args(),
+			All:        *all,
+			Filter:     filter,
+			Status:     resolution,
+			Resolution: *note,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("set %d entries %s\n", resolved, resolution)
+		return nil
+	case "purge":
+		purged, err := u.Purge(ctx, model.DLQPurgeRequest{
+			IDs:    flags.Args(),
+			All:    *all,
+			Filter: filter,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("purged %d entries\n", purged)
+		return nil
+	case "backfill":
+		result, err := u.Backfill(ctx)
+		if result != nil {
+			fmt.Printf("imported %d, recorded already %d, skipped %d\n", result.Imported, result.Recorded, result.Skipped)
+		}
+		return err
+	default:
+		return errors.New(dlqUsage)
+	}
+}
+
+func printDLQEntries(entries []model.DLQEntry) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPRODUCT\tFAILED AT\tATTEMPTS\tSTATUS\tCLASS\tERROR")
+	for _, entry := range entries {
+		errorText := strings.ReplaceAll(entry.Error, "\n", " ")
+		if len(errorText) > maxErrorWidth {
+			errorText = errorText[:maxErrorWidth-1] + "…"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			entry.ID,
+			entry.ProductID,
+			entry.FailedAt.Format(time.RFC3339),
+			entry.Attempts,
+			entry.Status,
+			entry.ErrorClass,
+			errorText)
+	}
+	return w.Flush()
+}
+
+func printDLQSummary(summary []model.DLQSummary) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLASS\tSTATUS\tENTRIES\tPRODUCTS\tLAST FAILED AT")
+	for _, row := range summary {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n",
+			row.ErrorClass,
+			row.Status,
+			row.Count,
+			row.Products,
+			row.LastFailed.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,55 @@
+// cmd/api/gc.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/usecase/product"
+)
+
+const gcUsage = `usage: api gc [-dry-run]`
+
+// runGC handles the `gc` subcommand.
+func runGC(gc *product.GarbageCollector, args []string) error {
+	flags := flag.NewFlagSet("gc", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list the orphaned objects without deleting them")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errors.New(gcUsage)
+	}
+
+	report, err := gc.Collect(context.Background(), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tSIZE\tLAST MODIFIED")
+		for _, info := range report.Orphaned {
+			fmt.Fprintf(w, "%s\t%d\t%s\n",
+				info.Key,
+				info.Size,
+				info.LastModified.Format(time.RFC3339))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("scanned %d objects, %d within the grace period\n", report.Scanned, report.Recent)
+	fmt.Printf("orphaned %d objects, %d bytes\n", len(report.Orphaned), report.OrphanedSize)
+	if !*dryRun {
+		fmt.Printf("deleted %d, failed %d\n", report.Deleted, report.Failed)
+		if report.Failed > 0 {
+			return fmt.Errorf("failed to delete %d objects", report.Failed)
+		}
+	}
+	return nil
+}
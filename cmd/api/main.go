@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -14,11 +15,15 @@ import (
 
 	"github.com/iSparshP/product-management-system/internal/api/handler"
 	"github.com/iSparshP/product-management-system/internal/api/router"
+	"github.com/iSparshP/product-management-system/internal/domain/model"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/config"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/kafka"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/logger"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/objectstore"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/postgres"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/redis"
+	"github.com/iSparshP/product-management-system/internal/usecase/dlq"
+	"github.com/iSparshP/product-management-system/internal/usecase/outbox"
 	"github.com/iSparshP/product-management-system/internal/usecase/product"
 )
 
@@ -45,26 +50,106 @@ func main() {
 	dsn := postgres.BuildDSN(pgConfig)
 	db := postgres.NewPostgresDB(dsn)
 
+	// Run the migrate subcommand instead of the server when requested
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(db, logInstance, os.Args[2:]); err != nil {
+			logInstance.Fatal("Migration failed", zap.Error(err))
+		}
+		return
+	}
+
+	if cfg.DBAutoMigrate {
+		migrator, err := postgres.NewMigrator(db, logInstance)
+		if err != nil {
+			logInstance.Fatal("Failed to load migrations", zap.Error(err))
+		}
+		if err := migrator.Up(context.Background(), 0); err != nil {
+			logInstance.Fatal("Failed to migrate database", zap.Error(err))
+		}
+	}
+
+	// Initialize Object Store
+	store, err := objectstore.New(cfg, logInstance)
+	if err != nil {
+		logInstance.Fatal("Failed to initialize object store", zap.Error(err))
+	}
+
+	// Run the gc subcommand instead of the server when requested; it only
+	// needs the database and the object store
+	if len(os.Args) > 1 && os.Args[1] == "gc" {
+		gc := product.NewGarbageCollector(postgres.NewObjectReferenceRepo(db), store, cfg.GCGracePeriod, logInstance)
+		if err := runGC(gc, os.Args[2:]); err != nil {
+			logInstance.Fatal("Garbage collection failed", zap.Error(err))
+		}
+		return
+	}
+
 	// Initialize Redis
 	redisClient := redis.NewRedisClient(cfg.RedisAddr)
 
 	// Initialize Kafka Publisher
-	kafkaPub, err := kafka.NewPublisher(cfg.KafkaBrokers, "image_processing", logInstance)
+	kafkaPub, err := kafka.NewPublisher(cfg.KafkaBrokers, model.ImageProcessingTopic, logInstance)
 	if err != nil {
 		logInstance.Fatal("Failed to initialize Kafka publisher", zap.Error(err))
 	}
 
+	urlBuilder, err := objectstore.NewURLBuilder(store, cfg)
+	if err != nil {
+		logInstance.Fatal("Invalid object URL configuration", zap.Error(err))
+	}
+
 	// Initialize Repositories
 	productRepo := postgres.NewProductRepo(db)
+	outboxRepo := postgres.NewOutboxRepo(db)
+	imageStatusRepo := postgres.NewImageStatusRepo(db)
+	dlqRepo := postgres.NewDLQRepo(db)
+	processedImageRepo := postgres.NewProcessedImageRepo(db)
 
 	// Initialize Usecases
-	productUsecase := product.NewProductUsecase(productRepo, kafkaPub, redisClient, logInstance)
+	productUsecase := product.NewProductUsecase(productRepo, imageStatusRepo, store, urlBuilder, product.UploadConfig{
+		MaxBytes: int64(cfg.UploadMaxBytes),
+		// Presigned uploads are fetched by the image processor, so they share its limit
+		MaxPresignedBytes: int64(cfg.ImageFetchMaxBytes),
+		URLExpiry:         cfg.UploadURLExpiry,
+	}, redisClient, logInstance)
+	dlqReader, err := kafka.NewDLQReader(cfg.KafkaBrokers, model.ImageProcessingDLQTopic, redisClient)
+	if err != nil {
+		logInstance.Fatal("Failed to initialize Kafka DLQ reader", zap.Error(err))
+	}
+	defer dlqReader.Close()
+	dlqUsecase := dlq.NewDLQUsecase(dlqRepo, kafkaPub, dlqReader, logInstance)
+
+	// Run the dlq admin subcommand instead of the server when requested
+	if len(os.Args) > 1 && os.Args[1] == "dlq" {
+		if err := runDLQ(dlqUsecase, os.Args[2:]); err != nil {
+			logInstance.Fatal("DLQ command failed", zap.Error(err))
+		}
+		return
+	}
+
+	// Start Background Workers
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start Trash Purger
+	trashPurger := product.NewTrashPurger(productRepo, processedImageRepo, store, cfg.TrashRetention, cfg.TrashPurgeInterval, logInstance)
+	go trashPurger.Start(ctx)
+
+	// Start Outbox Relay
+	outboxRelay := outbox.NewRelay(outboxRepo, kafkaPub, cfg.OutboxPollInterval, cfg.OutboxRetention, logInstance)
+	go outboxRelay.Start(ctx)
 
 	// Initialize Handlers
 	productHandler := handler.NewProductHandler(productUsecase, logInstance)
+	dlqHandler := handler.NewDLQHandler(dlqUsecase, logInstance)
+	// Objects of the local store are served by the API
+	var fileHandler *handler.FileHandler
+	if objectstore.ServedByAPI(cfg.StorageDriver) {
+		fileHandler = handler.NewFileHandler(store, cfg.StorageSignedURLs, logInstance)
+	}
 
 	// Setup Router
-	r := router.SetupRouter(productHandler, logInstance)
+	r := router.SetupRouter(productHandler, dlqHandler, fileHandler, redisClient, cfg.AdminToken, logInstance)
 
 	// Start Server
 	go func() {
@@ -4,18 +4,24 @@ package main
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/iSparshP/product-management-system/internal/domain/model"
 	"github.com/iSparshP/product-management-system/internal/imageprocessor/service"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/config"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/kafka"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/logger"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/objectstore"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/postgres"
 )
 
@@ -58,24 +64,71 @@ func main() {
 	dsn := postgres.BuildDSN(pgConfig)
 	db := postgres.NewPostgresDB(dsn)
 
+	if cfg.DBAutoMigrate {
+		migrator, err := postgres.NewMigrator(db, logInstance)
+		if err != nil {
+			logInstance.Fatal("Failed to load migrations", zap.Error(err))
+		}
+		if err := migrator.Up(context.Background(), 0); err != nil {
+			logInstance.Fatal("Failed to migrate database", zap.Error(err))
+		}
+	}
+
 	// Initialize Kafka Consumer
-	kafkaConsumer, err := kafka.NewConsumer(cfg.KafkaBrokers, "image_processing_group", "image_processing", logInstance)
+	kafkaConsumer, err := kafka.NewConsumer(cfg.KafkaBrokers, "image_processing_group", model.ImageProcessingTopic, logInstance)
 	if err != nil {
 		logInstance.Fatal("Failed to initialize Kafka consumer", zap.Error(err))
 	}
 
+	// One consumer per retry tier, each in its own group
+	var retryConsumers []*kafka.Consumer
+	for _, delay := range cfg.ImageRetryDelays {
+		topic := model.ImageRetryTopic(delay)
+		groupID := "image_processing_group" + strings.TrimPrefix(topic, model.ImageProcessingTopic)
+		retryConsumer, err := kafka.NewRetryConsumer(cfg.KafkaBrokers, groupID, topic, logInstance)
+		if err != nil {
+			logInstance.Fatal("Failed to initialize Kafka retry consumer", zap.String("topic", topic), zap.Error(err))
+		}
+		retryConsumers = append(retryConsumers, retryConsumer)
+	}
+
 	// Initialize Repositories
 	productRepo := postgres.NewProductRepo(db)
+	imageStatusRepo := postgres.NewImageStatusRepo(db)
+	dlqRepo := postgres.NewDLQRepo(db)
+	processedImageRepo := postgres.NewProcessedImageRepo(db)
+
+	// Serve metrics
+	if cfg.MetricsAddr != "" {
+		metricsServer := &http.Server{
+			Addr:    cfg.MetricsAddr,
+			Handler: expvar.Handler(),
+		}
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logInstance.Error("Metrics server failed", zap.Error(err))
+			}
+		}()
+		defer metricsServer.Close()
+	}
+
+	// Initialize Object Store
+	store, err := objectstore.New(cfg, logInstance)
+	if err != nil {
+		logInstance.Fatal("Failed to initialize object store", zap.Error(err))
+	}
 
 	// Initialize Image Processor Service
-	imgProcessor := service.NewImageProcessor(kafkaConsumer, productRepo, cfg, logInstance)
+	imgProcessor := service.NewImageProcessor(kafkaConsumer, retryConsumers, productRepo, imageStatusRepo, dlqRepo, processedImageRepo, store, cfg, logInstance)
 
 	// Start Image Processor
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	stopped := make(chan struct{})
 	go func() {
-		if err := imgProcessor.Start(ctx); err != nil {
+		defer close(stopped)
+		if err := imgProcessor.Start(ctx); err != nil && !errors.Is(err, context.Canceled) {
 			logInstance.Fatal("Image processor encountered an error", zap.Error(err))
 		}
 	}()
@@ -86,4 +139,14 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logInstance.Info("Shutting down Image Processor...")
+
+	// Cancel in-flight tasks, left unmarked for redelivery, and commit the
+	// offsets of those already done
+	cancel()
+	<-stopped
+	for _, consumer := range append([]*kafka.Consumer{kafkaConsumer}, retryConsumers...) {
+		if err := consumer.Close(); err != nil {
+			logInstance.Error("Failed to close Kafka consumer", zap.Error(err))
+		}
+	}
 }
@@ -0,0 +1,193 @@
+// internal/api/handler/dlq_handler.go
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"github.com/iSparshP/product-management-system/internal/usecase/dlq"
+)
+
+// Listings return the newest entries, at most maxDLQListLimit of them
+const (
+	defaultDLQListLimit = 100
+	maxDLQListLimit     = 1000
+)
+
+type DLQHandler struct {
+	usecase dlq.Usecase
+	logger  *zap.Logger
+}
+
+func NewDLQHandler(u dlq.Usecase, logger *zap.Logger) *DLQHandler {
+	return &DLQHandler{
+		usecase: u,
+		logger:  logger,
+	}
+}
+
+// ListEntries lists the DLQ entries matching the query filter.
+func (h *DLQHandler) ListEntries(c *gin.Context) {
+	var filter model.DLQFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultDLQListLimit
+	}
+	filter.Limit = min(filter.Limit, maxDLQListLimit)
+
+	entries, err := h.usecase.List(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Failed to list DLQ entries", zap.Error(err))
+		if errors.Is(err, dlq.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list DLQ entries"})
+		return
+	}
+	if entries == nil {
+		entries = []model.DLQEntry{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// Summarize counts the DLQ entries matching the query filter by error class
+// and status.
+func (h *DLQHandler) Summarize(c *gin.Context) {
+	var filter model.DLQFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := h.usecase.Summarize(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Failed to summarize DLQ entries", zap.Error(err))
+		if errors.Is(err, dlq.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize DLQ entries"})
+		return
+	}
+	if summary == nil {
+		summary = []model.DLQSummary{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+func (h *DLQHandler) GetEntry(c *gin.Context) {
+	id := c.Param("id")
+	entry, err := h.usecase.Get(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get DLQ entry", zap.String("dlq_id", id), zap.Error(err))
+		if errors.Is(err, repository.ErrDLQEntryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "DLQ entry not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get DLQ entry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *DLQHandler) ReplayEntries(c *gin.Context) {
+	var req model.DLQReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.usecase.Replay(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Failed to replay DLQ entries", zap.Error(err))
+		if status, ok := selectionErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		// Entries replayed before the failure stay replayed
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay DLQ entries", "result": result})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *DLQHandler) ResolveEntries(c *gin.Context) {
+	var req model.DLQResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resolved, err := h.usecase.Resolve(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Failed to resolve DLQ entries", zap.Error(err))
+		if status, ok := selectionErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve DLQ entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"resolved": resolved})
+}
+
+func (h *DLQHandler) PurgeEntries(c *gin.Context) {
+	var req model.DLQPurgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	purged, err := h.usecase.Purge(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Failed to purge DLQ entries", zap.Error(err))
+		if status, ok := selectionErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge DLQ entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
+
+// BackfillEntries imports the messages of the DLQ topic published before
+// failures were recorded as entries.
+func (h *DLQHandler) BackfillEntries(c *gin.Context) {
+	result, err := h.usecase.Backfill(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to backfill DLQ entries", zap.Error(err))
+		// Messages imported before the failure stay imported
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to backfill DLQ entries", "result": result})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// selectionErrorStatus maps errors about the entries a request selected.
+func selectionErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, dlq.ErrNoSelection), errors.Is(err, dlq.ErrInvalidFilter):
+		return http.StatusBadRequest, true
+	case errors.Is(err, repository.ErrDLQEntryNotFound):
+		return http.StatusNotFound, true
+	}
+	return 0, false
+}
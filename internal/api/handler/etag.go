@@ -0,0 +1,66 @@
+// internal/api/handler/etag.go
+
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+)
+
+// parseIfMatch turns an If-Match header into a version precondition. If-Match
+// uses strong comparison, so weak tags never match and are skipped.
+func parseIfMatch(header string) (model.VersionPrecondition, bool) {
+	var precondition model.VersionPrecondition
+	for _, tag := range splitETags(header) {
+		if tag == "*" {
+			return model.AnyVersion, true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		version, ok := parseVersionETag(tag)
+		if !ok {
+			return precondition, false
+		}
+		precondition.Versions = append(precondition.Versions, version)
+	}
+	return precondition, true
+}
+
+// ifNoneMatch reports whether an If-None-Match header matches the product's
+// current version, using weak comparison as required for GET.
+func ifNoneMatch(header string, product *model.Product) bool {
+	for _, tag := range splitETags(header) {
+		if tag == "*" {
+			return true
+		}
+		if version, ok := parseVersionETag(strings.TrimPrefix(tag, "W/")); ok && version == product.Version {
+			return true
+		}
+	}
+	return false
+}
+
+func splitETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func parseVersionETag(tag string) (int64, bool) {
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil || !strings.HasPrefix(tag, `"`) {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,139 @@
+// internal/api/handler/file_handler.go
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+)
+
+// fileMaxAge is how long, in seconds, clients may cache served objects
+const fileMaxAge = 86400
+
+// signatureVerifier is implemented by the stores that presign URLs of
+// objects served by the API.
+type signatureVerifier interface {
+	VerifySignature(method, key, expires, size, signature string) error
+}
+
+// FileHandler serves the objects of the stores without a public endpoint of
+// their own, and takes the uploads presigned for them.
+type FileHandler struct {
+	store storage.ObjectStore
+	// signed requires a valid signature to serve an object, as objects are
+	// private when URLs are presigned
+	signed bool
+	logger *zap.Logger
+}
+
+func NewFileHandler(store storage.ObjectStore, signed bool, logger *zap.Logger) *FileHandler {
+	return &FileHandler{
+		store:  store,
+		signed: signed,
+		logger: logger,
+	}
+}
+
+// ServeFile streams an object. Objects are public unless URLs are signed,
+// except uploads: they are the sellers' originals, metadata included, and
+// are only served to presigned URLs. Presigned URLs are checked all the
+// same, so expired links stop working. Private objects may only be cached by
+// the client, until their URL expires.
+// The checksum of an object is its ETag, for clients to revalidate it.
+func (h *FileHandler) ServeFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if storage.ValidateKey(key) != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	cacheControl := fmt.Sprintf("public, max-age=%d", fileMaxAge)
+	private := h.signed || strings.HasPrefix(key, model.UploadKeyPrefix)
+	if signature := c.Query("signature"); signature != "" || private {
+		verifier, ok := h.store.(signatureVerifier)
+		if !ok || verifier.VerifySignature(http.MethodGet, key, c.Query("expires"), "", signature) != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired signature"})
+			return
+		}
+		if private {
+			expires, _ := strconv.ParseInt(c.Query("expires"), 10, 64)
+			cacheControl = fmt.Sprintf("private, max-age=%d", min(fileMaxAge, max(expires-time.Now().Unix(), 0)))
+		}
+	}
+
+	body, info, err := h.store.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		h.logger.Error("Failed to read file", zap.String("key", key), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer body.Close()
+
+	headers := map[string]string{
+		"Last-Modified": info.LastModified.UTC().Format(http.TimeFormat),
+		"Cache-Control": cacheControl,
+	}
+	if info.Checksum != "" {
+		etag := `"` + info.Checksum + `"`
+		headers["ETag"] = etag
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			for name, value := range headers {
+				c.Header(name, value)
+			}
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, body, headers)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, weak
+// validators included.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// PutFile stores the body of a presigned upload. The signature covers the
+// key and the size, which the body must match exactly.
+func (h *FileHandler) PutFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	verifier, ok := h.store.(signatureVerifier)
+	if !ok || verifier.VerifySignature(http.MethodPut, key, c.Query("expires"), c.Query("size"), c.Query("signature")) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired signature"})
+		return
+	}
+	size, err := strconv.ParseInt(c.Query("size"), 10, 64)
+	if err != nil || c.Request.ContentLength != size {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Body must be exactly %s bytes", c.Query("size"))})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, size)
+	if err := h.store.Put(c.Request.Context(), key, body, storage.ContentType(key)); err != nil {
+		h.logger.Error("Failed to store file", zap.String("key", key), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
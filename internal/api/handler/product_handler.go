@@ -3,14 +3,21 @@
 package handler
 
 import (
+	"errors"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
 	"github.com/iSparshP/product-management-system/internal/usecase/product"
+	"github.com/iSparshP/product-management-system/pkg/utils"
 )
 
 type ProductHandler struct {
@@ -33,14 +40,19 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.usecase.CreateProduct(c.Request.Context(), input)
+	createdProduct, err := h.usecase.CreateProduct(c.Request.Context(), input)
 	if err != nil {
 		h.logger.Error("Failed to create product", zap.Error(err))
+		if errors.Is(err, product.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, product)
+	c.Header("ETag", createdProduct.ETag())
+	c.JSON(http.StatusCreated, createdProduct)
 }
 
 func (h *ProductHandler) GetProductByID(c *gin.Context) {
@@ -48,7 +60,17 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 	product, err := h.usecase.GetProductByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get product by ID", zap.String("id", id), zap.Error(err))
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		if errors.Is(err, repository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product"})
+		return
+	}
+
+	c.Header("ETag", product.ETag())
+	if ifNoneMatch(c.GetHeader("If-None-Match"), product) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
@@ -57,40 +79,417 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 
 func (h *ProductHandler) GetProducts(c *gin.Context) {
 	userID := c.Query("user_id")
-	minPriceStr := c.Query("min_price")
-	maxPriceStr := c.Query("max_price")
-	name := c.Query("name")
-
 	if userID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
 		return
 	}
 
-	filters := make(map[string]interface{})
-	if minPriceStr != "" {
-		minPrice, err := strconv.ParseFloat(minPriceStr, 64)
-		if err == nil {
-			filters["min_price"] = minPrice
+	filter, page, fieldErrors := parseProductListQuery(c)
+	if len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "fields": fieldErrors})
+		return
+	}
+
+	products, err := h.usecase.GetProducts(c.Request.Context(), userID, filter, page)
+	if err != nil {
+		h.logger.Error("Failed to get products", zap.Error(err))
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "fields": model.ValidationErrors{"cursor": "is invalid or was issued for a different sort"}})
+			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// UpdateProduct replaces a product. The If-Match header with the product's
+// ETag is required so concurrent edits are not silently overwritten.
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	id := c.Param("id")
+	precondition, ok := h.requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	var input model.UpdateProductInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.logger.Error("Invalid input for UpdateProduct", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if maxPriceStr != "" {
-		maxPrice, err := strconv.ParseFloat(maxPriceStr, 64)
-		if err == nil {
-			filters["max_price"] = maxPrice
+	updatedProduct, err := h.usecase.UpdateProduct(c.Request.Context(), id, input, precondition)
+	if err != nil {
+		h.logger.Error("Failed to update product", zap.String("id", id), zap.Error(err))
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		case errors.Is(err, repository.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified, fetch it again and retry"})
+		case errors.Is(err, product.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
 		}
+		return
 	}
 
-	if name != "" {
-		filters["name"] = name
+	c.Header("ETag", updatedProduct.ETag())
+	c.JSON(http.StatusOK, updatedProduct)
+}
+
+// PatchProduct applies a JSON merge patch (RFC 7396) to a product.
+// Like UpdateProduct it requires If-Match.
+func (h *ProductHandler) PatchProduct(c *gin.Context) {
+	id := c.Param("id")
+	precondition, ok := h.requireIfMatch(c)
+	if !ok {
+		return
 	}
 
-	products, err := h.usecase.GetProducts(c.Request.Context(), userID, filters)
+	patch, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		h.logger.Error("Failed to get products", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get products"})
+		h.logger.Error("Failed to read patch body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	updatedProduct, err := h.usecase.PatchProduct(c.Request.Context(), id, patch, precondition)
+	if err != nil {
+		h.logger.Error("Failed to patch product", zap.String("id", id), zap.Error(err))
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		case errors.Is(err, repository.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified, fetch it again and retry"})
+		case errors.Is(err, product.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to patch product"})
+		}
+		return
+	}
+
+	c.Header("ETag", updatedProduct.ETag())
+	c.JSON(http.StatusOK, updatedProduct)
+}
+
+// DeleteProduct moves a product to the trash. If-Match is optional here.
+func (h *ProductHandler) DeleteProduct(c *gin.Context) {
+	id := c.Param("id")
+	precondition := model.AnyVersion
+	if header := c.GetHeader("If-Match"); header != "" {
+		parsed, ok := parseIfMatch(header)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Malformed If-Match header"})
+			return
+		}
+		precondition = parsed
+	}
+
+	if err := h.usecase.DeleteProduct(c.Request.Context(), id, precondition); err != nil {
+		h.logger.Error("Failed to delete product", zap.String("id", id), zap.Error(err))
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		case errors.Is(err, repository.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified, fetch it again and retry"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ProductHandler) GetTrash(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	products, err := h.usecase.GetTrash(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get trashed products", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get trashed products"})
 		return
 	}
 
 	c.JSON(http.StatusOK, products)
 }
+
+func (h *ProductHandler) RestoreProduct(c *gin.Context) {
+	id := c.Param("id")
+	restoredProduct, err := h.usecase.RestoreProduct(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to restore product", zap.String("id", id), zap.Error(err))
+		if errors.Is(err, repository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found in trash"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore product"})
+		return
+	}
+
+	c.Header("ETag", restoredProduct.ETag())
+	c.JSON(http.StatusOK, restoredProduct)
+}
+
+func (h *ProductHandler) GetImageStatus(c *gin.Context) {
+	id := c.Param("id")
+	report, err := h.usecase.GetImageStatus(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get image status", zap.String("id", id), zap.Error(err))
+		if errors.Is(err, repository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get image status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetImage redirects to the variant of a rendition of one product image that
+// best matches the Accept header, e.g. WebP for browsers that accept it.
+func (h *ProductHandler) GetImage(c *gin.Context) {
+	id := c.Param("id")
+	position, err := strconv.Atoi(c.Param("position"))
+	if err != nil || position < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "position must be a non-negative integer"})
+		return
+	}
+	rendition := c.Param("rendition")
+
+	storedProduct, err := h.usecase.GetProductByID(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get product for image", zap.String("id", id), zap.Error(err))
+		if errors.Is(err, repository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product"})
+		return
+	}
+
+	variants, err := storedProduct.Variants()
+	if err != nil {
+		h.logger.Error("Failed to decode image variants", zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get image"})
+		return
+	}
+
+	c.Header("Vary", "Accept")
+	if position < len(variants) {
+		if variant, ok := model.SelectVariant(variants[position], rendition, c.GetHeader("Accept")); ok {
+			c.Redirect(http.StatusFound, variant.URL)
+			return
+		}
+	}
+
+	// Images processed before variants existed only have their renditions
+	if renditions := utils.JSONToRenditions(storedProduct.CompressedProductImages); position < len(renditions) {
+		if url, ok := renditions[position][rendition]; ok {
+			c.Redirect(http.StatusFound, url)
+			return
+		}
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+}
+
+// PresignUpload returns a URL the client PUTs an image to. The returned key
+// is then listed in product_images.
+func (h *ProductHandler) PresignUpload(c *gin.Context) {
+	var input model.PresignUploadInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.logger.Error("Invalid input for PresignUpload", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	upload, err := h.usecase.PresignUpload(c.Request.Context(), input)
+	if err != nil {
+		h.logger.Error("Failed to presign upload", zap.Error(err))
+		if errors.Is(err, product.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to presign upload"})
+		return
+	}
+
+	c.JSON(http.StatusOK, upload)
+}
+
+// UploadImage stores the image sent in the "file" field of a multipart form
+// as an upload of the user_id query parameter. The part is streamed to the
+// store rather than buffered.
+func (h *ProductHandler) UploadImage(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expected a multipart/form-data body"})
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		if err != nil {
+			h.logger.Error("Failed to read multipart body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		upload, err := h.usecase.UploadImage(c.Request.Context(), userID, part)
+		if err != nil {
+			h.logger.Error("Failed to upload image", zap.Error(err))
+			if errors.Is(err, product.ErrInvalidInput) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, upload)
+		return
+	}
+}
+
+// requireIfMatch reads the If-Match precondition of a write, responding with
+// 428 when it is missing and 400 when it is malformed.
+func (h *ProductHandler) requireIfMatch(c *gin.Context) (model.VersionPrecondition, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the product ETag is required"})
+		return model.VersionPrecondition{}, false
+	}
+
+	precondition, ok := parseIfMatch(header)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Malformed If-Match header"})
+		return model.VersionPrecondition{}, false
+	}
+
+	return precondition, true
+}
+
+// parseProductListQuery reads the filter and page of a product listing from the
+// query string, collecting parse and validation failures per field.
+func parseProductListQuery(c *gin.Context) (model.ProductFilter, model.PageRequest, model.ValidationErrors) {
+	fieldErrors := model.ValidationErrors{}
+	filter := model.ProductFilter{
+		Search:        strings.TrimSpace(c.Query("search")),
+		Name:          c.Query("name"),
+		MinPrice:      parseFloatQuery(c, "min_price", fieldErrors),
+		MaxPrice:      parseFloatQuery(c, "max_price", fieldErrors),
+		CreatedAfter:  parseTimeQuery(c, "created_after", fieldErrors),
+		CreatedBefore: parseTimeQuery(c, "created_before", fieldErrors),
+		UpdatedAfter:  parseTimeQuery(c, "updated_after", fieldErrors),
+		UpdatedBefore: parseTimeQuery(c, "updated_before", fieldErrors),
+	}
+
+	if value := c.Query("has_compressed_images"); value != "" {
+		hasCompressedImages, err := strconv.ParseBool(value)
+		if err != nil {
+			fieldErrors.Add("has_compressed_images", "must be true or false")
+		} else {
+			filter.HasCompressedImages = &hasCompressedImages
+		}
+	}
+
+	// ids may be repeated or given as a comma-separated list
+	for _, value := range c.QueryArray("ids") {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				filter.IDs = append(filter.IDs, id)
+			}
+		}
+	}
+
+	if err := filter.Validate(); err != nil {
+		var validationErrors model.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			for field, message := range validationErrors {
+				fieldErrors.Add(field, message)
+			}
+		}
+	}
+
+	var limit int
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			fieldErrors.Add("limit", "must be an integer")
+		}
+		limit = parsed
+	}
+
+	// Searches are ordered by relevance unless another sort is requested
+	sort := c.Query("sort")
+	if sort == "" && filter.Search != "" {
+		sort = string(model.SortByRelevance)
+	}
+
+	page, err := model.NewPageRequest(limit, sort, c.Query("order"), c.Query("cursor"))
+	if err != nil {
+		var validationErrors model.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			for field, message := range validationErrors {
+				fieldErrors.Add(field, message)
+			}
+		}
+	}
+	if page.Sort == model.SortByRelevance && filter.Search == "" {
+		fieldErrors.Add("sort", "relevance requires a search query")
+	}
+
+	return filter, page, fieldErrors
+}
+
+func parseFloatQuery(c *gin.Context, key string, fieldErrors model.ValidationErrors) *float64 {
+	value := c.Query(key)
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		fieldErrors.Add(key, "must be a number")
+		return nil
+	}
+	return &parsed
+}
+
+// parseTimeQuery accepts RFC 3339 timestamps or plain dates (YYYY-MM-DD, UTC).
+func parseTimeQuery(c *gin.Context, key string, fieldErrors model.ValidationErrors) *time.Time {
+	value := c.Query(key)
+	if value == "" {
+		return nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed
+	}
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return &parsed
+	}
+	fieldErrors.Add(key, "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	return nil
+}
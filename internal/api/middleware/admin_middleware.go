@@ -0,0 +1,34 @@
+// internal/api/middleware/admin_middleware.go
+
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminAuthMiddleware guards the admin endpoints with a bearer token. The
+// endpoints are disabled altogether when no token is configured.
+func AdminAuthMiddleware(token string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Admin API is disabled"})
+			return
+		}
+
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			logger.Warn("Rejected admin request",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("client_ip", c.ClientIP()))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin token"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,188 @@
+// internal/api/middleware/idempotency_middleware.go
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/iSparshP/product-management-system/internal/infrastructure/redis"
+)
+
+const (
+	idempotencyHeader       = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyLockTTL bounds how long a crashed request blocks its key.
+	idempotencyLockTTL = time.Minute
+	// idempotencyTTL is how long completed responses are replayed.
+	idempotencyTTL = 24 * time.Hour
+)
+
+const (
+	idempotencyInProgress = "in_progress"
+	idempotencyCompleted  = "completed"
+)
+
+// replayedHeaders are the response headers stored and replayed with the body.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+type idempotencyRecord struct {
+	Status      string            `json:"status"`
+	Fingerprint string            `json:"fingerprint"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// IdempotencyMiddleware makes requests carrying an Idempotency-Key header safe
+// to retry. The first request claims the key in Redis and its response is
+// stored; repeats with the same body get that response replayed, repeats with
+// a different body get 409, and repeats while the first is still running get 425.
+// Requests without the header are passed through unchanged.
+func IdempotencyMiddleware(redisClient *redis.Client, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		cacheKey := "idempotency:" + c.Request.Method + ":" + c.FullPath() + ":" + key
+		fingerprint := requestFingerprint(c.Request.Method, c.FullPath(), body)
+
+		claim, _ := json.Marshal(idempotencyRecord{Status: idempotencyInProgress, Fingerprint: fingerprint})
+		acquired, err := redisClient.SetNX(ctx, cacheKey, claim, idempotencyLockTTL)
+		if err != nil {
+			// Redis being unavailable should not take request handling down with it
+			logger.Warn("Failed to claim idempotency key, processing without it",
+				zap.Error(err),
+				zap.String("idempotency_key", key))
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			replayIdempotentResponse(c, redisClient, cacheKey, fingerprint, logger)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// Store the outcome even if the client has gone away meanwhile
+		storeIdempotentResponse(context.WithoutCancel(ctx), redisClient, cacheKey, fingerprint, recorder, logger)
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, redisClient *redis.Client, cacheKey, fingerprint string, logger *zap.Logger) {
+	stored, err := redisClient.Get(c.Request.Context(), cacheKey)
+	if err != nil {
+		// The claim expired or was released between SetNX and Get; let the client retry
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusTooEarly, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(stored), &record); err != nil {
+		logger.Error("Failed to unmarshal idempotency record", zap.Error(err), zap.String("cache_key", cacheKey))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+		return
+	}
+
+	switch {
+	case record.Fingerprint != fingerprint:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Idempotency-Key was already used with a different request"})
+	case record.Status == idempotencyInProgress:
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusTooEarly, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+	default:
+		for name, value := range record.Headers {
+			c.Header(name, value)
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Status(record.StatusCode)
+		c.Writer.Write(record.Body)
+		c.Abort()
+	}
+}
+
+// storeIdempotentResponse saves the response for replay. Server errors release
+// the key instead, so the client can retry the request for real.
+func storeIdempotentResponse(ctx context.Context, redisClient *redis.Client, cacheKey, fingerprint string, recorder *responseRecorder, logger *zap.Logger) {
+	if recorder.Status() >= http.StatusInternalServerError {
+		if err := redisClient.Del(ctx, cacheKey).Err(); err != nil {
+			logger.Warn("Failed to release idempotency key", zap.Error(err), zap.String("cache_key", cacheKey))
+		}
+		return
+	}
+
+	record := idempotencyRecord{
+		Status:      idempotencyCompleted,
+		Fingerprint: fingerprint,
+		StatusCode:  recorder.Status(),
+		Headers:     make(map[string]string),
+		Body:        recorder.body.Bytes(),
+	}
+	for _, name := range replayedHeaders {
+		if value := recorder.Header().Get(name); value != "" {
+			record.Headers[name] = value
+		}
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		logger.Error("Failed to marshal idempotency record", zap.Error(err), zap.String("cache_key", cacheKey))
+		return
+	}
+	if err := redisClient.Set(ctx, cacheKey, data, idempotencyTTL); err != nil {
+		logger.Warn("Failed to store idempotent response", zap.Error(err), zap.String("cache_key", cacheKey))
+	}
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder copies the response body while writing it to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
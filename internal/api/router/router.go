@@ -4,14 +4,25 @@ package router
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 
 	"github.com/iSparshP/product-management-system/internal/api/handler"
 	"github.com/iSparshP/product-management-system/internal/api/middleware"
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/redis"
 )
 
 // SetupRouter initializes the Gin router with necessary middleware and routes.
-func SetupRouter(productHandler *handler.ProductHandler, logger *zap.Logger) *gin.Engine {
+// fileHandler is nil unless the object store is served by the API.
+func SetupRouter(productHandler *handler.ProductHandler, dlqHandler *handler.DLQHandler, fileHandler *handler.FileHandler, redisClient *redis.Client, adminToken string, logger *zap.Logger) *gin.Engine {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		if err := model.RegisterValidations(v); err != nil {
+			logger.Fatal("Failed to register validations", zap.Error(err))
+		}
+	}
+
 	r := gin.New()
 	r.SetTrustedProxies([]string{"127.0.0.1"})
 	r.Use(gin.Recovery())
@@ -21,10 +32,40 @@ func SetupRouter(productHandler *handler.ProductHandler, logger *zap.Logger) *gi
 	{
 		products := v1.Group("/products")
 		{
-			products.POST("", productHandler.CreateProduct)
+			products.POST("", middleware.IdempotencyMiddleware(redisClient, logger), productHandler.CreateProduct)
+			products.GET("/trash", productHandler.GetTrash)
+			products.POST("/:id/restore", productHandler.RestoreProduct)
 			products.GET("/:id", productHandler.GetProductByID)
+			products.GET("/:id/images/status", productHandler.GetImageStatus)
+			products.GET("/:id/images/:position/:rendition", productHandler.GetImage)
 			products.GET("", productHandler.GetProducts)
+			products.PUT("/:id", productHandler.UpdateProduct)
+			products.PATCH("/:id", productHandler.PatchProduct)
+			products.DELETE("/:id", productHandler.DeleteProduct)
+		}
+
+		uploads := v1.Group("/uploads")
+		{
+			uploads.POST("", productHandler.UploadImage)
+			uploads.POST("/presign", productHandler.PresignUpload)
 		}
+
+		admin := v1.Group("/admin", middleware.AdminAuthMiddleware(adminToken, logger))
+		{
+			dlq := admin.Group("/dlq")
+			dlq.GET("", dlqHandler.ListEntries)
+			dlq.GET("/summary", dlqHandler.Summarize)
+			dlq.GET("/:id", dlqHandler.GetEntry)
+			dlq.POST("/replay", dlqHandler.ReplayEntries)
+			dlq.POST("/resolve", dlqHandler.ResolveEntries)
+			dlq.POST("/purge", dlqHandler.PurgeEntries)
+			dlq.POST("/backfill", dlqHandler.BackfillEntries)
+		}
+	}
+
+	if fileHandler != nil {
+		r.GET("/files/*key", fileHandler.ServeFile)
+		r.PUT("/files/*key", fileHandler.PutFile)
 	}
 
 	// Health Check Endpoint
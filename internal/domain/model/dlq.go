@@ -1,12 +1,137 @@
+// internal/domain/model/dlq.go
+
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
 
+// DLQMessage is published to the image processing DLQ topic when a task
+// fails for good.
 type DLQMessage struct {
+	// ID is the id of the DLQ entry recorded for the message. Messages
+	// published before entries were recorded have none
+	ID             string              `json:"id,omitempty"`
 	TaskID         string              `json:"task_id"`
 	OriginalTask   ImageProcessingTask `json:"original_task"`
+	ErrorClass     ErrorClass          `json:"error_class"`
 	Error          string              `json:"error"`
+	FailedURLs     []string            `json:"failed_urls,omitempty"`
 	PartialResults []string            `json:"partial_results,omitempty"`
 	Timestamp      time.Time           `json:"timestamp"`
 	RetryCount     int                 `json:"retry_count"`
 }
+
+// DLQStatus is the resolution state of a DLQ entry.
+type DLQStatus string
+
+const (
+	DLQStatusOpen     DLQStatus = "open"
+	DLQStatusReplayed DLQStatus = "replayed"
+	DLQStatusIgnored  DLQStatus = "ignored"
+)
+
+// IsValid reports whether s is a known DLQ status.
+func (s DLQStatus) IsValid() bool {
+	switch s {
+	case DLQStatusOpen, DLQStatusReplayed, DLQStatusIgnored:
+		return true
+	}
+	return false
+}
+
+// DLQEntry is a failed image task kept for querying and resolution. Entries
+// start open and are resolved by replaying or ignoring them.
+type DLQEntry struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ProductID  uuid.UUID  `gorm:"type:uuid;not null" json:"product_id"`
+	ErrorClass ErrorClass `gorm:"type:varchar(50);not null" json:"error_class"`
+	Error      string     `gorm:"type:text;not null" json:"error"`
+	// FailedURLs lists the source images that could not be processed
+	FailedURLs datatypes.JSON `gorm:"type:jsonb;not null;default:'[]'" json:"failed_urls"`
+	// PartialResults lists the objects uploaded before the task failed
+	PartialResults datatypes.JSON `gorm:"type:jsonb;not null;default:'[]'" json:"partial_results"`
+	Attempts       int            `gorm:"not null" json:"attempts"`
+	// Task is the original ImageProcessingTask, replayed as is
+	Task       datatypes.JSON `gorm:"type:jsonb;not null" json:"task"`
+	Status     DLQStatus      `gorm:"type:varchar(20);not null;default:open" json:"status"`
+	Resolution string         `gorm:"type:text;not null;default:''" json:"resolution,omitempty"`
+	ResolvedAt *time.Time     `json:"resolved_at,omitempty"`
+	FailedAt   time.Time      `gorm:"not null" json:"failed_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+func (DLQEntry) TableName() string {
+	return "dlq_entries"
+}
+
+// DLQFilter selects DLQ entries. Zero fields match everything.
+type DLQFilter struct {
+	ProductID  string     `form:"product_id" json:"product_id"`
+	ErrorClass ErrorClass `form:"error_class" json:"error_class"`
+	Status     DLQStatus  `form:"status" json:"status"`
+	// Error matches entries whose error contains it, ignoring case
+	Error string    `form:"error" json:"error"`
+	Since time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00" json:"since"`
+	Until time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00" json:"until"`
+	// Limit caps the number of entries listed, newest first; 0 lists all
+	Limit int `form:"limit" json:"-"`
+}
+
+// DLQReplayRequest selects the DLQ entries to replay: those listed in IDs,
+// or with All every open entry matching the filter.
+type DLQReplayRequest struct {
+	IDs    []string  `json:"ids"`
+	All    bool      `json:"all"`
+	Filter DLQFilter `json:"filter"`
+	// OnlyFailed reprocesses only the images that were not uploaded
+	OnlyFailed bool `json:"only_failed"`
+}
+
+// DLQResolveRequest sets the status of the selected DLQ entries, selected
+// like in DLQReplayRequest. Ignoring closes entries that need no replay;
+// setting them open again reopens them.
+type DLQResolveRequest struct {
+	IDs        []string  `json:"ids"`
+	All        bool      `json:"all"`
+	Filter     DLQFilter `json:"filter"`
+	Status     DLQStatus `json:"status"`
+	Resolution string    `json:"resolution"`
+}
+
+// DLQPurgeRequest selects the DLQ entries to delete, selected like in
+// DLQReplayRequest.
+type DLQPurgeRequest struct {
+	IDs    []string  `json:"ids"`
+	All    bool      `json:"all"`
+	Filter DLQFilter `json:"filter"`
+}
+
+// DLQBackfillResult reports the messages of the DLQ topic a backfill read:
+// those imported as entries, those recorded as entries already and those
+// skipped, purged or undecodable.
+type DLQBackfillResult struct {
+	Imported int `json:"imported"`
+	Recorded int `json:"recorded"`
+	Skipped  int `json:"skipped"`
+}
+
+// DLQReplayResult reports which entries were replayed; entries resolved
+// before are skipped.
+type DLQReplayResult struct {
+	Replayed []string `json:"replayed"`
+	Skipped  []string `json:"skipped"`
+}
+
+// DLQSummary counts the DLQ entries of each error class and status.
+type DLQSummary struct {
+	ErrorClass ErrorClass `json:"error_class"`
+	Status     DLQStatus  `json:"status"`
+	Count      int64      `json:"count"`
+	Products   int64      `json:"products"`
+	LastFailed time.Time  `json:"last_failed_at"`
+}
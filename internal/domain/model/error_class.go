@@ -0,0 +1,58 @@
+// internal/domain/model/error_class.go
+
+package model
+
+// ErrorClass categorises why processing an image failed. It decides whether
+// a failure is retried and labels image statuses, DLQ entries and metrics.
+type ErrorClass string
+
+const (
+	// Source image failures, reported by the server hosting it
+	ErrorClassNotFound    ErrorClass = "not_found"
+	ErrorClassForbidden   ErrorClass = "forbidden"
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	ErrorClassUpstream5xx ErrorClass = "upstream_5xx"
+	// ErrorClassInvalidSource covers other rejected requests: invalid URLs,
+	// other 4xx responses and redirect loops
+	ErrorClassInvalidSource ErrorClass = "invalid_source"
+	ErrorClassTimeout       ErrorClass = "timeout"
+	// ErrorClassNetwork covers refused and reset connections and DNS failures
+	ErrorClassNetwork ErrorClass = "network"
+
+	// Source image content failures
+	ErrorClassUnsupportedFormat ErrorClass = "unsupported_format"
+	ErrorClassCorruptImage      ErrorClass = "corrupt_image"
+	ErrorClassTooLarge          ErrorClass = "too_large"
+
+	// Failures of the processor's own dependencies
+	ErrorClassStorageFailure ErrorClass = "storage_failure"
+	ErrorClassDBFailure      ErrorClass = "db_failure"
+
+	ErrorClassUnknown ErrorClass = "unknown"
+	// ErrorClassMixed labels DLQ entries whose images failed for different reasons
+	ErrorClassMixed ErrorClass = "mixed"
+)
+
+// Retryable reports whether failures of the class may succeed if retried.
+func (c ErrorClass) Retryable() bool {
+	switch c {
+	case ErrorClassRateLimited, ErrorClassUpstream5xx, ErrorClassTimeout, ErrorClassNetwork,
+		ErrorClassStorageFailure, ErrorClassDBFailure:
+		return true
+	}
+	return false
+}
+
+// ClassifiedError is an error labelled with its class.
+type ClassifiedError struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
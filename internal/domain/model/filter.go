@@ -0,0 +1,72 @@
+// internal/domain/model/filter.go
+
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxFilterIDs        = 100
+	maxFilterNameLength = 255
+	maxSearchLength     = 255
+)
+
+// ProductFilter narrows a product listing. Nil and empty fields are not applied.
+// Date ranges are inclusive of the lower bound and exclusive of the upper bound.
+// Search is a full-text query over product name and description.
+type ProductFilter struct {
+	Search              string     `json:"search,omitempty"`
+	Name                string     `json:"name,omitempty"`
+	MinPrice            *float64   `json:"min_price,omitempty"`
+	MaxPrice            *float64   `json:"max_price,omitempty"`
+	CreatedAfter        *time.Time `json:"created_after,omitempty"`
+	CreatedBefore       *time.Time `json:"created_before,omitempty"`
+	UpdatedAfter        *time.Time `json:"updated_after,omitempty"`
+	UpdatedBefore       *time.Time `json:"updated_before,omitempty"`
+	HasCompressedImages *bool      `json:"has_compressed_images,omitempty"`
+	IDs                 []string   `json:"ids,omitempty"`
+}
+
+// Validate checks the filter values and ranges, reporting every invalid field.
+func (f ProductFilter) Validate() error {
+	errs := ValidationErrors{}
+
+	if len(f.Search) > maxSearchLength {
+		errs.Add("search", fmt.Sprintf("must be at most %d characters", maxSearchLength))
+	}
+	if len(f.Name) > maxFilterNameLength {
+		errs.Add("name", fmt.Sprintf("must be at most %d characters", maxFilterNameLength))
+	}
+	if f.MinPrice != nil && *f.MinPrice < 0 {
+		errs.Add("min_price", "must not be negative")
+	}
+	if f.MaxPrice != nil && *f.MaxPrice < 0 {
+		errs.Add("max_price", "must not be negative")
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		errs.Add("min_price", "must not be greater than max_price")
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		errs.Add("created_after", "must be before created_before")
+	}
+	if f.UpdatedAfter != nil && f.UpdatedBefore != nil && !f.UpdatedAfter.Before(*f.UpdatedBefore) {
+		errs.Add("updated_after", "must be before updated_before")
+	}
+	if len(f.IDs) > maxFilterIDs {
+		errs.Add("ids", fmt.Sprintf("must contain at most %d ids", maxFilterIDs))
+	}
+	for _, id := range f.IDs {
+		if _, err := uuid.Parse(id); err != nil {
+			errs.Add("ids", fmt.Sprintf("%q is not a valid UUID", id))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// internal/domain/model/image_status.go
+
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ImageStatus is the processing state of a single source image.
+type ImageStatus string
+
+const (
+	ImageStatusPending     ImageStatus = "pending"
+	ImageStatusDownloading ImageStatus = "downloading"
+	ImageStatusProcessing  ImageStatus = "processing"
+	ImageStatusUploaded    ImageStatus = "uploaded"
+	// ImageStatusRetrying marks a failed image whose task is scheduled for retry
+	ImageStatusRetrying ImageStatus = "retrying"
+	ImageStatusFailed   ImageStatus = "failed"
+)
+
+// ImageProcessingStatus is the processing state of all images of a product.
+type ImageProcessingStatus string
+
+const (
+	ImageProcessingPending    ImageProcessingStatus = "pending"
+	ImageProcessingInProgress ImageProcessingStatus = "processing"
+	ImageProcessingCompleted  ImageProcessingStatus = "completed"
+	ImageProcessingPartial    ImageProcessingStatus = "partial"
+	ImageProcessingFailed     ImageProcessingStatus = "failed"
+)
+
+// ProductImageStatus tracks one source image, identified by its position in
+// the product's images.
+type ProductImageStatus struct {
+	ProductID uuid.UUID   `gorm:"type:uuid;primaryKey" json:"product_id"`
+	Position  int         `gorm:"primaryKey" json:"position"`
+	SourceURL string      `gorm:"type:text;not null" json:"source_url"`
+	Status    ImageStatus `gorm:"type:varchar(20);not null;default:pending" json:"status"`
+	Error     string      `gorm:"type:text;not null;default:''" json:"error,omitempty"`
+	// ErrorClass categorises Error
+	ErrorClass ErrorClass `gorm:"type:varchar(30);not null;default:''" json:"error_class,omitempty"`
+	Attempts   int        `gorm:"not null;default:0" json:"attempts"`
+	// Digest identifies the ProcessedImage the source image produced
+	Digest string `gorm:"type:varchar(64);not null;default:''" json:"digest,omitempty"`
+	// Renditions maps rendition names to object keys once the image is uploaded
+	Renditions datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"renditions"`
+	// Variants lists every uploaded encoding of the renditions
+	Variants datatypes.JSON `gorm:"type:jsonb;not null;default:'[]'" json:"variants"`
+	// Original dimensions, once upright, and format of the source image
+	OriginalWidth  int    `gorm:"not null;default:0" json:"original_width,omitempty"`
+	OriginalHeight int    `gorm:"not null;default:0" json:"original_height,omitempty"`
+	OriginalFormat string `gorm:"type:varchar(10);not null;default:''" json:"original_format,omitempty"`
+	// Metadata holds the ImageMetadata kept from the source image
+	Metadata  datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"metadata"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// ImageMetadata is the source image metadata kept once the image is
+// processed. Renditions carry no metadata at all; location and any other
+// EXIF or XMP field not listed here are dropped.
+type ImageMetadata struct {
+	// Orientation is the EXIF orientation the image was rotated by
+	Orientation int    `json:"orientation,omitempty"`
+	CameraMake  string `json:"camera_make,omitempty"`
+	CameraModel string `json:"camera_model,omitempty"`
+	CapturedAt  string `json:"captured_at,omitempty"`
+	// ColorProfile describes the embedded ICC profile
+	ColorProfile string `json:"color_profile,omitempty"`
+	// ConvertedToSRGB is set when colors were converted from the profile
+	ConvertedToSRGB bool `json:"converted_to_srgb,omitempty"`
+}
+
+// ProductImageStatusReport is the response of the image status endpoint.
+type ProductImageStatusReport struct {
+	ProductID             uuid.UUID             `json:"product_id"`
+	ImageProcessingStatus ImageProcessingStatus `json:"image_processing_status"`
+	Images                []ProductImageStatus  `json:"images"`
+}
+
+// ImageProcessingResult is what the image processor stores on a product. Its
+// slices are aligned with the source images; failed images have nil entries.
+type ImageProcessingResult struct {
+	CompressedImages []map[string]string
+	Variants         [][]ImageVariant
+	Status           ImageProcessingStatus
+}
+
+// AggregateImageStatus derives the product-level status from its images.
+func AggregateImageStatus(statuses []ProductImageStatus) ImageProcessingStatus {
+	uploaded, failed := 0, 0
+	for _, status := range statuses {
+		switch status.Status {
+		case ImageStatusUploaded:
+			uploaded++
+		case ImageStatusFailed:
+			failed++
+		}
+	}
+
+	switch {
+	case len(statuses) == 0:
+		return ImageProcessingPending
+	case uploaded == len(statuses):
+		return ImageProcessingCompleted
+	case uploaded+failed < len(statuses):
+		return ImageProcessingInProgress
+	case uploaded > 0:
+		return ImageProcessingPartial
+	default:
+		return ImageProcessingFailed
+	}
+}
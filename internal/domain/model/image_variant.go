@@ -0,0 +1,168 @@
+// internal/domain/model/image_variant.go
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ImageVariant is one encoding of a rendition of a source image. Variants
+// are stored with the key of their object; URL is set when they are read.
+type ImageVariant struct {
+	Rendition string      `json:"rendition"`
+	Format    ImageFormat `json:"format"`
+	Key       string      `json:"key,omitempty"`
+	URL       string      `json:"url,omitempty"`
+	Width     int         `json:"width"`
+	Height    int         `json:"height"`
+}
+
+// Ref returns the stored reference to the variant's object: its key, or the
+// URL of variants stored before keys were.
+func (v ImageVariant) Ref() string {
+	if v.Key != "" {
+		return v.Key
+	}
+	return v.URL
+}
+
+// variantPreference orders formats from most to least preferred when a
+// client accepts several. Lossless formats only compete when the rendition
+// has no JPEG, see servedVariants.
+var variantPreference = []ImageFormat{ImageFormatAVIF, ImageFormatWebP, ImageFormatPNG, ImageFormatJPEG}
+
+// Srcsets derives, for each image, a srcset per media type from its variants,
+// e.g. {"image/webp": "https://…/a.webp 150w, https://…/b.webp 400w"}.
+// Images without variants get a nil map.
+func Srcsets(images [][]ImageVariant) []map[string]string {
+	if len(images) == 0 {
+		return nil
+	}
+
+	srcsets := make([]map[string]string, len(images))
+	for i, variants := range images {
+		if len(variants) == 0 {
+			continue
+		}
+
+		byType := make(map[string][]ImageVariant)
+		for _, variant := range servedVariants(variants) {
+			mimeType := variant.Format.MimeType()
+			byType[mimeType] = append(byType[mimeType], variant)
+		}
+
+		srcsets[i] = make(map[string]string, len(byType))
+		for mimeType, sameType := range byType {
+			sort.SliceStable(sameType, func(a, b int) bool {
+				return sameType[a].Width < sameType[b].Width
+			})
+			candidates := make([]string, 0, len(sameType))
+			for _, variant := range sameType {
+				candidates = append(candidates, fmt.Sprintf("%s %dw", variant.URL, variant.Width))
+			}
+			srcsets[i][mimeType] = strings.Join(candidates, ", ")
+		}
+	}
+	return srcsets
+}
+
+// SelectVariant picks the variant of a rendition best suited to an Accept
+// header. The rendition's JPEG or PNG variant is returned when the client
+// accepts none of the others.
+func SelectVariant(variants []ImageVariant, rendition, accept string) (ImageVariant, bool) {
+	accepted := acceptedTypes(accept)
+	variants = servedVariants(variants)
+
+	var fallback *ImageVariant
+	for _, format := range variantPreference {
+		for i := range variants {
+			variant := variants[i]
+			if variant.Rendition != rendition || variant.Format != format {
+				continue
+			}
+			if accepted[variant.Format.MimeType()] {
+				return variant, true
+			}
+			if format == ImageFormatJPEG || format == ImageFormatPNG {
+				fallback = &variants[i]
+			}
+		}
+	}
+
+	if fallback != nil {
+		return *fallback, true
+	}
+	return ImageVariant{}, false
+}
+
+// servedVariants drops the lossless variants of the renditions that have a
+// JPEG variant, which is lighter, so that clients are never sent them.
+func servedVariants(variants []ImageVariant) []ImageVariant {
+	hasJPEG := make(map[string]bool)
+	for _, variant := range variants {
+		if variant.Format == ImageFormatJPEG {
+			hasJPEG[variant.Rendition] = true
+		}
+	}
+
+	served := make([]ImageVariant, 0, len(variants))
+	for _, variant := range variants {
+		if variant.Format.Lossless() && hasJPEG[variant.Rendition] {
+			continue
+		}
+		served = append(served, variant)
+	}
+	return served
+}
+
+// acceptedTypes lists the media types an Accept header names explicitly with
+// a non-zero quality. Wildcards are ignored: browsers send image/* even when
+// they cannot decode every image format.
+func acceptedTypes(accept string) map[string]bool {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || strings.Contains(mediaType, "*") {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if quality, err := strconv.ParseFloat(q, 64); err != nil || quality <= 0 {
+				continue
+			}
+		}
+		accepted[mediaType] = true
+	}
+	return accepted
+}
+
+// Variants decodes the image variants of the product, one list per product image.
+func (p Product) Variants() ([][]ImageVariant, error) {
+	var variants [][]ImageVariant
+	if len(p.ImageVariants) > 0 {
+		if err := json.Unmarshal(p.ImageVariants, &variants); err != nil {
+			return nil, fmt.Errorf("invalid image variants: %w", err)
+		}
+	}
+	return variants, nil
+}
+
+// MarshalJSON adds the srcsets derived from the image variants, so they are
+// computed when the product is read rather than stored.
+func (p Product) MarshalJSON() ([]byte, error) {
+	type product Product
+
+	variants, err := p.Variants()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(struct {
+		product
+		ImageSrcsets []map[string]string `json:"image_srcsets,omitempty"`
+	}{product(p), Srcsets(variants)})
+}
@@ -0,0 +1,78 @@
+// internal/domain/model/image_variant_test.go
+
+package model
+
+import "testing"
+
+func TestSelectVariant(t *testing.T) {
+	opaque := []ImageVariant{
+		{Rendition: "card", Format: ImageFormatJPEG, Key: "card.jpg"},
+		{Rendition: "card", Format: ImageFormatWebP, Key: "card.webp"},
+		{Rendition: "card", Format: ImageFormatAVIF, Key: "card.avif"},
+	}
+	transparent := []ImageVariant{
+		{Rendition: "card", Format: ImageFormatPNG, Key: "card.png"},
+		{Rendition: "card", Format: ImageFormatWebP, Key: "card.webp"},
+	}
+
+	tests := []struct {
+		name     string
+		variants []ImageVariant
+		accept   string
+		want     string
+	}{
+		{"AVIF first", opaque, "image/avif,image/webp,*/*", "card.avif"},
+		{"lossless WebP not preferred to JPEG", opaque, "image/webp,*/*", "card.jpg"},
+		{"JPEG fallback", opaque, "*/*", "card.jpg"},
+		{"zero quality", opaque, "image/avif;q=0,image/webp", "card.jpg"},
+		{"WebP preferred to PNG", transparent, "image/webp,image/png", "card.webp"},
+		{"PNG fallback", transparent, "image/*", "card.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, ok := SelectVariant(tt.variants, "card", tt.accept)
+			if !ok || variant.Key != tt.want {
+				t.Errorf("SelectVariant() = %q, %v, want %q", variant.Key, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := SelectVariant(opaque, "zoom", "image/webp"); ok {
+		t.Error("SelectVariant() found a variant of a missing rendition")
+	}
+}
+
+func TestSrcsetsLeaveOutLosslessVariants(t *testing.T) {
+	srcsets := Srcsets([][]ImageVariant{
+		{
+			{Rendition: "card", Format: ImageFormatJPEG, URL: "card.jpg", Width: 400},
+			{Rendition: "card", Format: ImageFormatWebP, URL: "card.webp", Width: 400},
+			{Rendition: "detail", Format: ImageFormatJPEG, URL: "detail.jpg", Width: 1200},
+		},
+		{
+			{Rendition: "card", Format: ImageFormatPNG, URL: "card.png", Width: 400},
+			{Rendition: "card", Format: ImageFormatWebP, URL: "card.webp", Width: 400},
+		},
+		nil,
+	})
+
+	want := []map[string]string{
+		{"image/jpeg": "card.jpg 400w, detail.jpg 1200w"},
+		{"image/png": "card.png 400w", "image/webp": "card.webp 400w"},
+		nil,
+	}
+	if len(srcsets) != len(want) {
+		t.Fatalf("got %d srcsets, want %d", len(srcsets), len(want))
+	}
+	for i := range want {
+		if len(srcsets[i]) != len(want[i]) {
+			t.Errorf("srcsets[%d] = %v, want %v", i, srcsets[i], want[i])
+			continue
+		}
+		for mimeType, srcset := range want[i] {
+			if srcsets[i][mimeType] != srcset {
+				t.Errorf("srcsets[%d][%s] = %q, want %q", i, mimeType, srcsets[i][mimeType], srcset)
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// internal/domain/model/outbox.go
+
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// OutboxMessage is a message waiting to be published to Kafka. AggregateID is
+// the product it belongs to and doubles as the Kafka message key, so messages
+// of one product are delivered in order.
+type OutboxMessage struct {
+	ID            int64          `gorm:"primaryKey"`
+	AggregateID   uuid.UUID      `gorm:"type:uuid;not null"`
+	Topic         string         `gorm:"type:varchar(255);not null"`
+	Payload       datatypes.JSON `gorm:"type:jsonb;not null"`
+	Attempts      int            `gorm:"not null;default:0"`
+	LastError     string         `gorm:"type:text;not null;default:''"`
+	NextAttemptAt time.Time      `gorm:"not null"`
+	LockedUntil   *time.Time
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
@@ -0,0 +1,83 @@
+// internal/domain/model/pagination.go
+
+package model
+
+import "fmt"
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// SortField is a product attribute the listing can be ordered by.
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByPrice     SortField = "price"
+	SortByName      SortField = "name"
+	// SortByRelevance orders by full-text search rank and needs a search query.
+	SortByRelevance SortField = "relevance"
+)
+
+// SortOrder is the direction of a listing.
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// PageRequest describes one page of a keyset-paginated listing.
+// Cursor is the opaque next_cursor of the previous page, empty for the first page.
+type PageRequest struct {
+	Limit  int
+	Sort   SortField
+	Order  SortOrder
+	Cursor string
+}
+
+// NewPageRequest builds a PageRequest, applying defaults for empty values.
+func NewPageRequest(limit int, sort, order, cursor string) (PageRequest, error) {
+	page := PageRequest{
+		Limit:  limit,
+		Sort:   SortField(sort),
+		Order:  SortOrder(order),
+		Cursor: cursor,
+	}
+	errs := ValidationErrors{}
+
+	if page.Limit == 0 {
+		page.Limit = DefaultPageLimit
+	}
+	if page.Limit < 1 || page.Limit > MaxPageLimit {
+		errs.Add("limit", fmt.Sprintf("must be between 1 and %d", MaxPageLimit))
+	}
+
+	switch page.Sort {
+	case "":
+		page.Sort = SortByCreatedAt
+	case SortByCreatedAt, SortByPrice, SortByName, SortByRelevance:
+	default:
+		errs.Add("sort", fmt.Sprintf("must be one of %s, %s, %s, %s", SortByCreatedAt, SortByPrice, SortByName, SortByRelevance))
+	}
+
+	switch page.Order {
+	case "":
+		page.Order = SortDesc
+	case SortAsc, SortDesc:
+	default:
+		errs.Add("order", fmt.Sprintf("must be %s or %s", SortAsc, SortDesc))
+	}
+
+	if len(errs) > 0 {
+		return page, errs
+	}
+	return page, nil
+}
+
+// ProductPage is the response envelope of the product listing.
+type ProductPage struct {
+	Data       []Product `json:"data"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,79 @@
+// internal/domain/model/processed_image.go
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ProcessedImage records the renditions and variants produced from a source
+// image, identified by a digest of its content and of the processing
+// settings. Products with the same image share them rather than processing
+// it again.
+type ProcessedImage struct {
+	Digest string `gorm:"type:varchar(64);primaryKey" json:"digest"`
+	// Renditions maps rendition names to the object keys of their primary format
+	Renditions datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"renditions"`
+	Variants   datatypes.JSON `gorm:"type:jsonb;not null;default:'[]'" json:"variants"`
+	// Original dimensions, format and kept metadata of the source image
+	OriginalWidth  int            `gorm:"not null;default:0" json:"original_width"`
+	OriginalHeight int            `gorm:"not null;default:0" json:"original_height"`
+	OriginalFormat string         `gorm:"type:varchar(10);not null;default:''" json:"original_format"`
+	Metadata       datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"metadata"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+func (ProcessedImage) TableName() string {
+	return "processed_images"
+}
+
+// Keys returns the object keys of the variants of the image.
+func (p ProcessedImage) Keys() ([]string, error) {
+	var variants []ImageVariant
+	if err := json.Unmarshal(p.Variants, &variants); err != nil {
+		return nil, fmt.Errorf("invalid processed image variants: %w", err)
+	}
+	keys := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		keys = append(keys, variant.Ref())
+	}
+	return keys, nil
+}
+
+// ProcessedImageReference records that a product uses a processed image.
+// The objects of a processed image are deleted with its last reference.
+type ProcessedImageReference struct {
+	Digest    string    `gorm:"type:varchar(64);primaryKey"`
+	ProductID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CreatedAt time.Time
+}
+
+func (ProcessedImageReference) TableName() string {
+	return "processed_image_references"
+}
+
+// ProductObjectPrefix starts the keys of the images processed for products.
+const ProductObjectPrefix = "products/"
+
+var processedImageKeyPattern = regexp.MustCompile(`^products/[0-9a-f-]{36}/[0-9a-f]{64}-`)
+
+// ProcessedImageKey returns the object key of a variant of a processed
+// image, under the product that first processed it, such as
+// products/<product_id>/<digest>-card.webp.
+func ProcessedImageKey(productID uuid.UUID, digest, rendition string, format ImageFormat) string {
+	return fmt.Sprintf("%s%s/%s-%s%s", ProductObjectPrefix, productID, digest, rendition, format.Extension())
+}
+
+// IsProcessedImageKey reports whether a key names a variant of a processed
+// image, whose lifetime follows its references, rather than an object
+// stored for one product before images were shared.
+func IsProcessedImageKey(key string) bool {
+	return processedImageKeyPattern.MatchString(key)
+}
@@ -3,22 +3,38 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type Product struct {
-	ID                      uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	UserID                  uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
-	ProductName             string         `gorm:"type:varchar(255);not null" json:"product_name"`
-	ProductDescription      string         `gorm:"type:text" json:"product_description"`
-	ProductImages           datatypes.JSON `gorm:"type:jsonb;not null" json:"product_images"`
-	CompressedProductImages datatypes.JSON `gorm:"type:jsonb" json:"compressed_product_images"`
-	ProductPrice            float64        `gorm:"type:decimal(10,2);not null" json:"product_price"`
-	CreatedAt               time.Time      `json:"created_at"`
-	UpdatedAt               time.Time      `json:"updated_at"`
+	ID                      uuid.UUID             `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID                  uuid.UUID             `gorm:"type:uuid;not null" json:"user_id"`
+	ProductName             string                `gorm:"type:varchar(255);not null" json:"product_name"`
+	ProductDescription      string                `gorm:"type:text" json:"product_description"`
+	ProductImages           datatypes.JSON        `gorm:"type:jsonb;not null" json:"product_images"`
+	CompressedProductImages datatypes.JSON        `gorm:"type:jsonb" json:"compressed_product_images"`
+	ImageVariants           datatypes.JSON        `gorm:"type:jsonb" json:"image_variants"`
+	ProductPrice            float64               `gorm:"type:decimal(10,2);not null" json:"product_price"`
+	ImageProcessingStatus   ImageProcessingStatus `gorm:"type:varchar(20);not null;default:pending" json:"image_processing_status"`
+	Version                 int64                 `gorm:"not null;default:1" json:"version"`
+	CreatedAt               time.Time             `json:"created_at"`
+	UpdatedAt               time.Time             `json:"updated_at"`
+	DeletedAt               gorm.DeletedAt        `gorm:"index" json:"deleted_at"`
+
+	// Populated by full-text search only
+	SearchRank    float64 `gorm:"->;-:migration" json:"search_rank,omitempty"`
+	SearchSnippet string  `gorm:"->;-:migration" json:"search_snippet,omitempty"`
+}
+
+// ProductCacheKey is the Redis key a product is cached under. The API
+// caches products and the image processor invalidates them.
+func ProductCacheKey(productID string) string {
+	return "product:" + productID
 }
 
 // CreateProductInput represents the input payload for creating a product.
@@ -26,12 +42,46 @@ type CreateProductInput struct {
 	UserID             string   `json:"user_id" binding:"required,uuid"`
 	ProductName        string   `json:"product_name" binding:"required"`
 	ProductDescription string   `json:"product_description" binding:"required"`
-	ProductImages      []string `json:"product_images" binding:"required,min=1,dive,url"`
+	ProductImages      []string `json:"product_images" binding:"required,min=1,dive,image_source"`
+	ProductPrice       float64  `json:"product_price" binding:"required,gt=0"`
+}
+
+// UpdateProductInput represents the input payload for replacing a product.
+// It is also the document a JSON merge patch is applied to.
+type UpdateProductInput struct {
+	ProductName        string   `json:"product_name" binding:"required"`
+	ProductDescription string   `json:"product_description" binding:"required"`
+	ProductImages      []string `json:"product_images" binding:"required,min=1,dive,image_source"`
 	ProductPrice       float64  `json:"product_price" binding:"required,gt=0"`
 }
 
+// ImageProcessingTopic is the Kafka topic image processing tasks are published to.
+const ImageProcessingTopic = "image_processing"
+
+// ImageProcessingDLQTopic receives the tasks that failed for good.
+const ImageProcessingDLQTopic = "image_processing_dlq"
+
+// ImageRetryTopic is the topic of the retry tier that delays tasks by delay,
+// such as image_processing.retry.5m.
+func ImageRetryTopic(delay time.Duration) string {
+	name := delay.String()
+	if strings.HasSuffix(name, "m0s") {
+		name = strings.TrimSuffix(name, "0s")
+	}
+	if strings.HasSuffix(name, "h0m") {
+		name = strings.TrimSuffix(name, "0m")
+	}
+	return ImageProcessingTopic + ".retry." + name
+}
+
 // ImageProcessingTask represents the task for processing images.
 type ImageProcessingTask struct {
 	ProductID string   `json:"product_id"`
 	ImageURLs []string `json:"image_urls"`
+	// OnlyFailed reuses the images already uploaded for the same source,
+	// for replays of failed tasks
+	OnlyFailed bool `json:"only_failed,omitempty"`
+	// Attempt numbers the deliveries of the task, from 1. It travels in a
+	// message header rather than in the payload.
+	Attempt int `json:"-"`
 }
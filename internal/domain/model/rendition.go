@@ -0,0 +1,156 @@
+// internal/domain/model/rendition.go
+
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultImageRenditions is the rendition profile used when none is configured.
+// "detail" matches the single compressed image produced before renditions existed.
+const DefaultImageRenditions = "thumbnail:150x150:fill:jpeg:75,card:400x400:fill:jpeg:80,detail:1200x1200:fit:jpeg:80,zoom:2400x2400:fit:jpeg:85"
+
+// RenditionMode controls how a source image is sized to a rendition.
+type RenditionMode string
+
+const (
+	// RenditionFit scales the image down to fit within the box, keeping its aspect ratio.
+	RenditionFit RenditionMode = "fit"
+	// RenditionFill scales and center-crops the image to exactly the box.
+	RenditionFill RenditionMode = "fill"
+	// RenditionCrop center-crops the image to the box without scaling it.
+	RenditionCrop RenditionMode = "crop"
+)
+
+// ImageFormat is the encoding of a rendition.
+type ImageFormat string
+
+const (
+	ImageFormatJPEG ImageFormat = "jpeg"
+	ImageFormatPNG  ImageFormat = "png"
+	ImageFormatWebP ImageFormat = "webp"
+	ImageFormatAVIF ImageFormat = "avif"
+)
+
+// DefaultImageVariantFormats are the formats produced alongside each rendition
+// when none are configured.
+const DefaultImageVariantFormats = "webp"
+
+// MimeType is the media type of images in the format.
+func (f ImageFormat) MimeType() string {
+	return "image/" + string(f)
+}
+
+// Lossless reports whether the processor encodes images in the format
+// losslessly. Lossless encodings of photos are several times heavier than
+// their JPEG: WebP is lossless until a pure-Go lossy encoder is available.
+func (f ImageFormat) Lossless() bool {
+	return f == ImageFormatPNG || f == ImageFormatWebP
+}
+
+// Extension is the file extension of images in the format.
+func (f ImageFormat) Extension() string {
+	if f == ImageFormatJPEG {
+		return ".jpg"
+	}
+	return "." + string(f)
+}
+
+// ParseImageFormats parses a comma-separated list of image formats.
+func ParseImageFormats(spec string) ([]ImageFormat, error) {
+	var formats []ImageFormat
+	for _, entry := range strings.Split(spec, ",") {
+		format := ImageFormat(strings.TrimSpace(entry))
+		switch format {
+		case "":
+			continue
+		case ImageFormatJPEG, ImageFormatPNG, ImageFormatWebP, ImageFormatAVIF:
+			if !slices.Contains(formats, format) {
+				formats = append(formats, format)
+			}
+		default:
+			return nil, fmt.Errorf("unknown image format %q", entry)
+		}
+	}
+	return formats, nil
+}
+
+// Rendition is one size the image processor produces for every source image.
+// Format is its primary format; opaque-only formats fall back to PNG for
+// images with transparency.
+type Rendition struct {
+	Name    string
+	Width   int
+	Height  int
+	Mode    RenditionMode
+	Format  ImageFormat
+	Quality int
+}
+
+// ParseRenditions parses a comma-separated rendition profile, each entry in
+// the form name:WIDTHxHEIGHT:mode:format:quality, e.g. "card:400x400:fill:jpeg:80".
+func ParseRenditions(spec string) ([]Rendition, error) {
+	var renditions []Rendition
+	seen := make(map[string]bool)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 5 {
+			return nil, fmt.Errorf("rendition %q must be name:WIDTHxHEIGHT:mode:format:quality", entry)
+		}
+
+		rendition := Rendition{
+			Name:   parts[0],
+			Mode:   RenditionMode(parts[2]),
+			Format: ImageFormat(parts[3]),
+		}
+		if rendition.Name == "" {
+			return nil, fmt.Errorf("rendition %q has no name", entry)
+		}
+		if seen[rendition.Name] {
+			return nil, fmt.Errorf("rendition %q is defined more than once", rendition.Name)
+		}
+		seen[rendition.Name] = true
+
+		width, height, ok := strings.Cut(parts[1], "x")
+		var errW, errH error
+		rendition.Width, errW = strconv.Atoi(width)
+		rendition.Height, errH = strconv.Atoi(height)
+		if !ok || errW != nil || errH != nil || rendition.Width < 1 || rendition.Height < 1 {
+			return nil, fmt.Errorf("rendition %q has an invalid size %q", rendition.Name, parts[1])
+		}
+
+		switch rendition.Mode {
+		case RenditionFit, RenditionFill, RenditionCrop:
+		default:
+			return nil, fmt.Errorf("rendition %q has an unknown mode %q", rendition.Name, parts[2])
+		}
+
+		switch rendition.Format {
+		case ImageFormatJPEG, ImageFormatPNG, ImageFormatWebP:
+		default:
+			return nil, fmt.Errorf("rendition %q has an unknown format %q", rendition.Name, parts[3])
+		}
+
+		quality, err := strconv.Atoi(parts[4])
+		if err != nil || quality < 1 || quality > 100 {
+			return nil, fmt.Errorf("rendition %q must have a quality between 1 and 100", rendition.Name)
+		}
+		rendition.Quality = quality
+
+		renditions = append(renditions, rendition)
+	}
+
+	if len(renditions) == 0 {
+		return nil, fmt.Errorf("at least one rendition is required")
+	}
+	return renditions, nil
+}
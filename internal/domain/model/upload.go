@@ -0,0 +1,96 @@
+// internal/domain/model/upload.go
+
+package model
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// UploadKeyPrefix prefixes the keys of images uploaded by sellers.
+const UploadKeyPrefix = "uploads/"
+
+// uploadExtensions are the extensions of the image types sellers may upload,
+// by media type
+var uploadExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+const uuidPattern = `[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`
+
+// uploadKeyPattern matches uploads/<user id>/<uuid>.<ext>. Uploads made before
+// keys recorded their uploader lack the user id.
+var uploadKeyPattern = regexp.MustCompile(`^uploads/(?:(` + uuidPattern + `)/)?` + uuidPattern + `\.(jpg|png|gif|webp)$`)
+
+// NewUploadKey returns a fresh object key for an upload of the given media
+// type by the user, reporting false for types that cannot be uploaded.
+func NewUploadKey(userID uuid.UUID, contentType string) (string, bool) {
+	ext, ok := uploadExtensions[contentType]
+	if !ok {
+		return "", false
+	}
+	return UploadKeyPrefix + userID.String() + "/" + uuid.NewString() + ext, true
+}
+
+// IsUploadKey reports whether a product image refers to an uploaded object
+// rather than an external URL.
+func IsUploadKey(source string) bool {
+	return uploadKeyPattern.MatchString(source)
+}
+
+// UploadOwner returns the user who uploaded the object of an upload key,
+// reporting false for other keys and for uploads that predate owners.
+func UploadOwner(key string) (string, bool) {
+	match := uploadKeyPattern.FindStringSubmatch(key)
+	if match == nil || match[1] == "" {
+		return "", false
+	}
+	return match[1], true
+}
+
+// PresignUploadInput asks for a URL to upload an image to.
+type PresignUploadInput struct {
+	UserID      string `json:"user_id" binding:"required,uuid"`
+	ContentType string `json:"content_type" binding:"required,oneof=image/jpeg image/png image/gif image/webp"`
+	// Size is the exact length of the image in bytes
+	Size int64 `json:"size" binding:"required,gt=0"`
+}
+
+// PresignedUpload tells the client how to upload an image. Once uploaded,
+// Key is used as a product image.
+type PresignedUpload struct {
+	Key       string            `json:"key"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// Upload is an image uploaded through the API.
+type Upload struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// RegisterValidations registers the custom binding tags of the model:
+// image_source accepts absolute http(s) URLs and upload keys.
+func RegisterValidations(v *validator.Validate) error {
+	return v.RegisterValidation("image_source", func(fl validator.FieldLevel) bool {
+		source := fl.Field().String()
+		if strings.HasPrefix(source, UploadKeyPrefix) {
+			return IsUploadKey(source)
+		}
+		u, err := url.Parse(source)
+		return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+	})
+}
@@ -0,0 +1,51 @@
+// internal/domain/model/upload_test.go
+
+package model
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUploadOwner(t *testing.T) {
+	const (
+		user   = "6f1d2e3b-0c4a-4b55-9a57-4f1c2a9e6b1d"
+		object = "4f1c2a9e-6b1d-4c55-9a57-0f6c1d2e3b4a"
+	)
+	tests := []struct {
+		key       string
+		isUpload  bool
+		wantOwner string
+	}{
+		{"uploads/" + user + "/" + object + ".jpg", true, user},
+		{"uploads/" + object + ".png", true, ""},
+		{"uploads/" + user + "/" + object + ".bmp", false, ""},
+		{"uploads/" + user + "/" + user + "/" + object + ".jpg", false, ""},
+		{"uploads/not-a-user/" + object + ".jpg", false, ""},
+		{"products/" + user + "/" + object + ".jpg", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := IsUploadKey(tt.key); got != tt.isUpload {
+				t.Errorf("IsUploadKey() = %v, want %v", got, tt.isUpload)
+			}
+			owner, ok := UploadOwner(tt.key)
+			if owner != tt.wantOwner || ok != (tt.wantOwner != "") {
+				t.Errorf("UploadOwner() = %q, %v, want %q", owner, ok, tt.wantOwner)
+			}
+		})
+	}
+
+	userID := uuid.MustParse(user)
+	key, ok := NewUploadKey(userID, "image/webp")
+	if !ok {
+		t.Fatal("NewUploadKey() refused image/webp")
+	}
+	if owner, _ := UploadOwner(key); owner != user {
+		t.Errorf("UploadOwner(NewUploadKey()) = %q, want %q", owner, user)
+	}
+	if _, ok := NewUploadKey(userID, "image/bmp"); ok {
+		t.Error("NewUploadKey() accepted image/bmp")
+	}
+}
@@ -0,0 +1,32 @@
+// internal/domain/model/validation.go
+
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// ValidationErrors maps an input field to the reason it was rejected.
+type ValidationErrors map[string]string
+
+func (v ValidationErrors) Error() string {
+	fields := make([]string, 0, len(v))
+	for field := range v {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field+": "+v[field])
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add records a failure for a field, keeping the first one reported.
+func (v ValidationErrors) Add(field, message string) {
+	if _, exists := v[field]; !exists {
+		v[field] = message
+	}
+}
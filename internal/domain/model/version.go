@@ -0,0 +1,33 @@
+// internal/domain/model/version.go
+
+package model
+
+import "strconv"
+
+// ETag returns the strong entity tag of the product's current version.
+func (p *Product) ETag() string {
+	return strconv.Quote(strconv.FormatInt(p.Version, 10))
+}
+
+// VersionPrecondition is the If-Match condition of a product write.
+// Any matches every existing version, as for "If-Match: *".
+type VersionPrecondition struct {
+	Any      bool
+	Versions []int64
+}
+
+// AnyVersion is the precondition of writes that were sent without If-Match.
+var AnyVersion = VersionPrecondition{Any: true}
+
+// Matches reports whether the product version satisfies the precondition.
+func (p VersionPrecondition) Matches(version int64) bool {
+	if p.Any {
+		return true
+	}
+	for _, v := range p.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,13 @@
 package processor
 
-import "github.com/iSparshP/product-management-system/internal/domain/model"
+import (
+	"context"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+)
 
 type ImageProcessor interface {
-	ProcessImageTask(task model.ImageProcessingTask) error
+	// ProcessImageTask processes the images of a task. It stops early once
+	// ctx is cancelled, returning an error that wraps ctx.Err().
+	ProcessImageTask(ctx context.Context, task model.ImageProcessingTask) error
 }
@@ -0,0 +1,36 @@
+// internal/domain/repository/dlq_repository.go
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+)
+
+type DLQRepository interface {
+	Create(ctx context.Context, entry *model.DLQEntry) error
+	// List returns the entries matching the filter, newest first.
+	List(ctx context.Context, filter model.DLQFilter) ([]model.DLQEntry, error)
+	// Summarize counts the entries matching the filter by error class and status.
+	Summarize(ctx context.Context, filter model.DLQFilter) ([]model.DLQSummary, error)
+	Get(ctx context.Context, id string) (*model.DLQEntry, error)
+	// MarkReplayed claims an open entry for replay, reporting false if it
+	// was resolved already.
+	MarkReplayed(ctx context.Context, id string, at time.Time) (bool, error)
+	// UnmarkReplayed reopens an entry whose replay failed.
+	UnmarkReplayed(ctx context.Context, id string) error
+	// Resolve sets the status and resolution of the entries, returning how
+	// many were changed.
+	Resolve(ctx context.Context, ids []string, status model.DLQStatus, resolution string, at time.Time) (int64, error)
+	// Purge deletes the entries, returning how many were deleted.
+	Purge(ctx context.Context, ids []string) (int64, error)
+	// Import creates an entry read from the DLQ topic unless one with its id
+	// exists, reporting whether it was created.
+	Import(ctx context.Context, entry *model.DLQEntry) (bool, error)
+	// BackfillOffsets returns the next offset to import of each partition of
+	// the DLQ topic imported from before.
+	BackfillOffsets(ctx context.Context) (map[int32]int64, error)
+	SaveBackfillOffset(ctx context.Context, partition int32, next int64) error
+}
@@ -0,0 +1,21 @@
+// internal/domain/repository/errors.go
+
+package repository
+
+import "errors"
+
+var (
+	// ErrProductNotFound is returned when a product does not exist.
+	ErrProductNotFound = errors.New("product not found")
+	// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrVersionConflict is returned when a product changed since it was read.
+	ErrVersionConflict = errors.New("product version conflict")
+	// ErrSourceImagesChanged is returned when compressed images are written for
+	// source images the product no longer has, or the product is gone.
+	ErrSourceImagesChanged = errors.New("product source images changed")
+	// ErrDLQEntryNotFound is returned when a DLQ entry does not exist or was purged.
+	ErrDLQEntryNotFound = errors.New("DLQ entry not found")
+	// ErrProcessedImageNotFound is returned when an image was not processed yet.
+	ErrProcessedImageNotFound = errors.New("processed image not found")
+)
@@ -0,0 +1,18 @@
+// internal/domain/repository/image_status_repository.go
+
+package repository
+
+import (
+	"context"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+)
+
+type ImageStatusRepository interface {
+	// Init prepares one status per source image. Positions whose source URL
+	// changed are reset to pending, positions beyond the images are removed.
+	Init(ctx context.Context, productID string, sourceURLs []string) ([]model.ProductImageStatus, error)
+	// Save stores the state of an image unless its position got a new source meanwhile.
+	Save(ctx context.Context, status *model.ProductImageStatus) error
+	GetByProductID(ctx context.Context, productID string) ([]model.ProductImageStatus, error)
+}
@@ -0,0 +1,16 @@
+// internal/domain/repository/object_reference_repository.go
+
+package repository
+
+import "context"
+
+// ObjectReferenceRepository reads the references to stored objects held
+// across tables, for the garbage collector.
+type ObjectReferenceRepository interface {
+	// List returns every stored reference, each once: the source images,
+	// compressed images and variants of products, trashed ones included,
+	// those of image statuses and of processed images some product still
+	// references, and the sources of DLQ tasks. References are object keys or
+	// URLs stored before keys were.
+	List(ctx context.Context) ([]string, error)
+}
@@ -0,0 +1,20 @@
+// internal/domain/repository/outbox_repository.go
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+)
+
+type OutboxRepository interface {
+	// ClaimPending leases up to limit due messages of a topic for lease. Only
+	// the oldest undelivered message of each aggregate is claimable, which
+	// keeps per-aggregate ordering across concurrent relays.
+	ClaimPending(ctx context.Context, topic string, limit int, lease time.Duration) ([]model.OutboxMessage, error)
+	MarkDelivered(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error
+	DeleteDelivered(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,27 @@
+// internal/domain/repository/processed_image_repository.go
+
+package repository
+
+import (
+	"context"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"gorm.io/datatypes"
+)
+
+type ProcessedImageRepository interface {
+	// Acquire references the processed image of a digest for a product,
+	// returning ErrProcessedImageNotFound when the image was not processed.
+	Acquire(ctx context.Context, digest, productID string) (*model.ProcessedImage, error)
+	// Save stores a processed image unless one with the same digest exists,
+	// references it for the product and returns the stored image. When a
+	// concurrent run saved the digest first, its image is returned instead.
+	Save(ctx context.Context, image *model.ProcessedImage, productID string) (*model.ProcessedImage, error)
+	// Replace overwrites the processed image of a digest if its variants are
+	// still staleVariants, reporting whether it did.
+	Replace(ctx context.Context, image *model.ProcessedImage, staleVariants datatypes.JSON) (bool, error)
+	// Release drops the references of a product to the processed images not
+	// listed in keep. The images no product references anymore are deleted
+	// and returned, for the caller to delete their objects.
+	Release(ctx context.Context, productID string, keep []string) ([]model.ProcessedImage, error)
+}
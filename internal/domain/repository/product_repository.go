@@ -4,13 +4,23 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/iSparshP/product-management-system/internal/domain/model"
 )
 
+// ProductRepository persists products. Create and Update store the given
+// outbox messages in the same transaction as the product change.
 type ProductRepository interface {
-	Create(ctx context.Context, product *model.Product) error
+	Create(ctx context.Context, product *model.Product, outbox ...model.OutboxMessage) error
 	GetByID(ctx context.Context, id string) (*model.Product, error)
-	GetAll(ctx context.Context, userID string, filters map[string]interface{}) ([]model.Product, error)
-	UpdateCompressedImages(ctx context.Context, id string, images []string) error
+	GetAll(ctx context.Context, userID string, filter model.ProductFilter, page model.PageRequest) (*model.ProductPage, error)
+	Update(ctx context.Context, product *model.Product, expectedVersion int64, outbox ...model.OutboxMessage) error
+	Delete(ctx context.Context, id string, expectedVersion int64) error
+	GetTrash(ctx context.Context, userID string) ([]model.Product, error)
+	Restore(ctx context.Context, id string) error
+	GetTrashedBefore(ctx context.Context, cutoff time.Time, limit int) ([]model.Product, error)
+	Purge(ctx context.Context, id string) error
+	UpdateImageProcessingStatus(ctx context.Context, id string, sourceImages []string, status model.ImageProcessingStatus) error
+	UpdateCompressedImages(ctx context.Context, id string, sourceImages []string, result model.ImageProcessingResult) error
 }
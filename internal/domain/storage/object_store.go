@@ -0,0 +1,132 @@
+// internal/domain/storage/object_store.go
+
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrObjectNotFound is returned when an object does not exist.
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrInvalidKey is returned for keys that are empty, absolute or escape
+	// their prefix with "..".
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// ChecksumMetadataKey names the object metadata holding the hex SHA-256 of
+// the content of S3 objects stored before S3 checksums were used.
+const ChecksumMetadataKey = "sha256"
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+	// Checksum is the hex SHA-256 of the content, when known; List leaves it empty
+	Checksum string
+}
+
+// Checksum hashes content as it is read, for stores to record the checksum
+// of a body without buffering it.
+type Checksum struct {
+	r    io.Reader
+	hash hash.Hash
+}
+
+func NewChecksum(r io.Reader) *Checksum {
+	return &Checksum{r: r, hash: sha256.New()}
+}
+
+func (c *Checksum) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.hash.Write(p[:n])
+	return n, err
+}
+
+// Sum returns the hex SHA-256 of the content read so far.
+func (c *Checksum) Sum() string {
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// ObjectStore stores the processed images. Objects are identified by
+// slash-separated keys such as "products/<name>.webp".
+type ObjectStore interface {
+	// Put stores body under key, replacing any object stored there. The body
+	// is streamed, and its checksum recorded with the object.
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Get opens an object; the caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete removes an object; deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	// List calls fn for every object whose key starts with prefix, stopping
+	// at the first error fn returns.
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+	// URL returns the public URL of an object.
+	URL(key string) string
+	// PresignedURL returns a URL granting read access to an object until
+	// expiry has passed.
+	PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// PresignedPutURL returns a URL accepting a PUT of exactly size bytes of
+	// contentType to key until expiry has passed.
+	PresignedPutURL(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (string, error)
+	// KeyFromURL returns the key of an object from a URL returned by URL,
+	// reporting false for URLs of other stores.
+	KeyFromURL(url string) (string, bool)
+}
+
+// URLResolver computes the URLs clients fetch objects from. Objects are
+// stored by key and their URLs computed when read, so they follow the
+// configuration and may expire.
+type URLResolver interface {
+	// ObjectURL returns the URL of a stored reference: an object key, or a
+	// URL stored before keys were. URLs outside the store are returned as is.
+	ObjectURL(ctx context.Context, ref string) (string, error)
+}
+
+// ObjectKey returns the key a stored reference refers to: the reference
+// itself, or the key of a URL of store. It reports false for URLs outside
+// the store.
+func ObjectKey(store ObjectStore, ref string) (string, bool) {
+	if !strings.Contains(ref, "://") {
+		return ref, ValidateKey(ref) == nil
+	}
+	return store.KeyFromURL(ref)
+}
+
+// ValidateKey rejects keys that could reach outside the store.
+func ValidateKey(key string) error {
+	if key == "" || key == "." || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") ||
+		path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// ContentType returns the media type of an object by its extension.
+func ContentType(key string) string {
+	switch strings.ToLower(path.Ext(key)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".avif":
+		return "image/avif"
+	default:
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,62 @@
+// internal/domain/storage/object_store_test.go
+
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"products/4f1c2a9e/abc-card.webp", true},
+		{"uploads/4f1c2a9e.jpg", true},
+		{"file.jpg", true},
+		{"products/..hidden/file.jpg", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../etc/passwd", false},
+		{"products/../../etc/passwd", false},
+		{"products/./file.jpg", false},
+		{"products//file.jpg", false},
+		{"products/", false},
+		{"/etc/passwd", false},
+		{`products\..\file.jpg`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := ValidateKey(tt.key)
+			if tt.valid && err != nil {
+				t.Errorf("ValidateKey(%q) = %v, want nil", tt.key, err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("ValidateKey(%q) = %v, want ErrInvalidKey", tt.key, err)
+			}
+		})
+	}
+}
+
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"a.jpg", "image/jpeg"},
+		{"a.JPEG", "image/jpeg"},
+		{"a.png", "image/png"},
+		{"a.gif", "image/gif"},
+		{"a.webp", "image/webp"},
+		{"a.avif", "image/avif"},
+		{"a.txt", "application/octet-stream"},
+		{"a", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		if got := ContentType(tt.key); got != tt.want {
+			t.Errorf("ContentType(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
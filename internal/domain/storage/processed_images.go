@@ -0,0 +1,31 @@
+// internal/domain/storage/processed_images.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+)
+
+// DeleteProcessedImages deletes the objects of processed images released by
+// their last reference. It carries on past failures and returns them joined;
+// the objects left behind are removed by the garbage collector.
+func DeleteProcessedImages(ctx context.Context, store ObjectStore, images []model.ProcessedImage) error {
+	var errs []error
+	for _, processed := range images {
+		keys, err := processed.Keys()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("processed image %s: %w", processed.Digest, err))
+			continue
+		}
+		for _, key := range keys {
+			if err := store.Delete(ctx, key); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete %s: %w", key, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,226 @@
+// internal/imageprocessor/fetcher/fetcher.go
+
+// Package fetcher downloads source images from seller-supplied URLs.
+//
+// URLs are untrusted, so every connection, including those made to follow
+// redirects, is checked against the address it actually dials: resolving a
+// public name to an internal address does not get through. Bodies are read up
+// to a size limit and must sniff as a supported image whose dimensions stay
+// under a pixel limit before anyone decodes them.
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL        = errors.New("invalid URL")
+	ErrSchemeNotAllowed  = errors.New("URL scheme not allowed")
+	ErrForbiddenAddress  = errors.New("address not allowed")
+	ErrTooManyRedirects  = errors.New("too many redirects")
+	ErrTooLarge          = errors.New("image exceeds the maximum size")
+	ErrTooManyPixels     = errors.New("image exceeds the maximum dimensions")
+	ErrUnsupportedFormat = errors.New("not a supported image")
+	ErrCorruptImage      = errors.New("corrupt image")
+)
+
+// supportedTypes are the sniffed media types the processor can decode
+var supportedTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp", "image/bmp"}
+
+// forbiddenPrefixes are the non-public ranges the net/netip predicates do not cover
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, embeds IPv4 addresses
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4, embeds IPv4 addresses
+	netip.MustParsePrefix("fec0::/10"),       // deprecated site-local
+	netip.MustParsePrefix("100::/64"),        // discard-only
+	netip.MustParsePrefix("2001::/32"),       // Teredo, embeds IPv4 addresses
+	netip.MustParsePrefix("::ffff:0:0:0/96"), // IPv4-translated
+}
+
+// Config bounds what the fetcher downloads.
+type Config struct {
+	AllowedSchemes []string
+	MaxRedirects   int
+	MaxBytes       int64
+	// MaxPixels bounds width times height, against decompression bombs
+	MaxPixels int64
+	Timeout   time.Duration
+	// AllowPrivateNetworks disables the address checks, for local development
+	AllowPrivateNetworks bool
+}
+
+type Fetcher struct {
+	Client *http.Client
+	Config Config
+}
+
+func NewFetcher(cfg Config) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = checkDialAddress
+	}
+
+	transport := &http.Transport{
+		// A proxy would dial on our behalf, out of reach of the address checks
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 15 * time.Second,
+	}
+
+	f := &Fetcher{Config: cfg}
+	f.Client = &http.Client{
+		Transport:     transport,
+		Timeout:       cfg.Timeout,
+		CheckRedirect: f.checkRedirect,
+	}
+	return f
+}
+
+// Fetch downloads the image at rawURL and returns its content once it is
+// known to be a supported image within the size and pixel limits.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if err := f.checkScheme(req); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(supportedTypes, ", "))
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+	if resp.ContentLength > f.Config.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLarge, resp.ContentLength)
+	}
+	return f.Read(resp.Body)
+}
+
+// Read reads an image from r with the limits and checks of Fetch, for
+// images that were uploaded rather than linked.
+func (f *Fetcher) Read(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, f.Config.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > f.Config.MaxBytes {
+		return nil, fmt.Errorf("%w of %d bytes", ErrTooLarge, f.Config.MaxBytes)
+	}
+
+	if err := f.checkImage(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// checkImage sniffs the content type, ignoring the one the server claims,
+// and reads the image dimensions from its header without decoding it.
+func (f *Fetcher) checkImage(data []byte) error {
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(supportedTypes, contentType) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormat, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// The content sniffed as a supported format, so its header is broken
+		return fmt.Errorf("%w: %v", ErrCorruptImage, err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > f.Config.MaxPixels {
+		return fmt.Errorf("%w: %dx%d", ErrTooManyPixels, cfg.Width, cfg.Height)
+	}
+	return nil
+}
+
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > f.Config.MaxRedirects {
+		return ErrTooManyRedirects
+	}
+	return f.checkScheme(req)
+}
+
+func (f *Fetcher) checkScheme(req *http.Request) error {
+	if !slices.Contains(f.Config.AllowedSchemes, strings.ToLower(req.URL.Scheme)) {
+		return fmt.Errorf("%w: %q", ErrSchemeNotAllowed, req.URL.Scheme)
+	}
+	return nil
+}
+
+// checkDialAddress rejects connections to non-public addresses. It runs
+// once names are resolved, for every connection attempt.
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublic(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	return nil
+}
+
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// StatusError is returned for non-2xx responses.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// Temporary reports whether the request may succeed if retried.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
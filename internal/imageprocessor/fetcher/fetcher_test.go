@@ -0,0 +1,197 @@
+// internal/imageprocessor/fetcher/fetcher_test.go
+
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.215.14", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"127.255.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"192.0.0.8", false},
+		{"198.18.0.1", false},
+		{"224.0.0.1", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"fc00::1", false},
+		{"fe80::1", false},
+		{"fec0::1", false},
+		{"ff02::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:0:7f00:1", false},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b:1::1", false},
+		{"2002:7f00:1::", false},
+		{"2001:0:4136:e378::1", false},
+		{"2001:db8::1", false},
+		{"100::1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isPublic(netip.MustParseAddr(tt.addr)); got != tt.public {
+				t.Errorf("isPublic(%s) = %v, want %v", tt.addr, got, tt.public)
+			}
+		})
+	}
+}
+
+func TestCheckDialAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr error
+	}{
+		{"93.184.215.14:443", nil},
+		{"[2606:4700:4700::1111]:443", nil},
+		{"127.0.0.1:80", ErrForbiddenAddress},
+		{"[::1]:80", ErrForbiddenAddress},
+		{"[::ffff:169.254.169.254]:80", ErrForbiddenAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := checkDialAddress("tcp", tt.address, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkDialAddress(%s) = %v, want %v", tt.address, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := checkDialAddress("tcp", "no-port", nil); err == nil {
+		t.Error("checkDialAddress() accepted an address without a port")
+	}
+	if err := checkDialAddress("tcp", "localhost:80", nil); err == nil {
+		t.Error("checkDialAddress() accepted an unresolved name")
+	}
+}
+
+func testConfig(allowPrivate bool) Config {
+	return Config{
+		AllowedSchemes:       []string{"http", "https"},
+		MaxRedirects:         2,
+		MaxBytes:             1 << 20,
+		MaxPixels:            100 * 100,
+		Timeout:              5 * time.Second,
+		AllowPrivateNetworks: allowPrivate,
+	}
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFetchRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testPNG(t, 1, 1))
+	}))
+	defer server.Close()
+
+	// The test server listens on loopback, which only development allows
+	if _, err := NewFetcher(testConfig(false)).Fetch(context.Background(), server.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("Fetch() error = %v, want ErrForbiddenAddress", err)
+	}
+	if _, err := NewFetcher(testConfig(true)).Fetch(context.Background(), server.URL); err != nil {
+		t.Errorf("Fetch() with private networks allowed error = %v", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	small := testPNG(t, 10, 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(small)
+	})
+	mux.HandleFunc("/huge.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testPNG(t, 200, 200))
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(append(small, make([]byte, 1<<20)...))
+	})
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("<html>not an image</html>"))
+	})
+	mux.HandleFunc("/corrupt.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(small[:20])
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/image.png", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/ftp", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "ftp://example.com/image.png", http.StatusFound)
+	})
+	mux.HandleFunc("/busy", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{"image", server.URL + "/image.png", nil},
+		{"redirect", server.URL + "/redirect", nil},
+		{"too many pixels", server.URL + "/huge.png", ErrTooManyPixels},
+		{"too large", server.URL + "/large", ErrTooLarge},
+		{"sniffed type wins", server.URL + "/text", ErrUnsupportedFormat},
+		{"corrupt header", server.URL + "/corrupt.png", ErrCorruptImage},
+		{"redirect loop", server.URL + "/loop", ErrTooManyRedirects},
+		{"redirect to another scheme", server.URL + "/ftp", ErrSchemeNotAllowed},
+		{"scheme", "file:///etc/passwd", ErrSchemeNotAllowed},
+		{"invalid URL", "http://[::1", ErrInvalidURL},
+	}
+	f := NewFetcher(testConfig(true))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := f.Fetch(context.Background(), tt.url)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Fetch() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !bytes.Equal(data, small) {
+				t.Error("Fetch() returned other content")
+			}
+		})
+	}
+
+	_, err := f.Fetch(context.Background(), server.URL+"/busy")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable || !statusErr.Temporary() {
+		t.Errorf("Fetch() error = %v, want a temporary StatusError 503", err)
+	}
+}
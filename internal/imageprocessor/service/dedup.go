@@ -0,0 +1,209 @@
+// internal/imageprocessor/service/dedup.go
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+	"go.uber.org/zap"
+)
+
+// processingVersion changes whenever the processing itself changes in a way
+// the settings do not show, so that images processed before are not reused.
+const processingVersion = 1
+
+// processingFingerprint hashes the settings that shape the processed images.
+func processingFingerprint(renditions []model.Rendition, variantFormats []model.ImageFormat, convertToSRGB bool) []byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%d|%+v|%v|%t", processingVersion, renditions, variantFormats, convertToSRGB)
+	return h.Sum(nil)
+}
+
+// imageDigest identifies the images produced from a source image with the
+// current settings.
+func (ip *ImageProcessor) imageDigest(data []byte) string {
+	h := sha256.New()
+	h.Write(ip.fingerprint)
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reuseProcessedImage completes the status with the processed image of its
+// digest if there is one whose objects all still exist. Any failure falls
+// back to processing the image.
+func (ip *ImageProcessor) reuseProcessedImage(ctx context.Context, status *model.ProductImageStatus) (map[string]string, []model.ImageVariant, bool) {
+	processed, err := ip.ProcessedImageRepo.Acquire(ctx, status.Digest, status.ProductID.String())
+	if err != nil {
+		if !errors.Is(err, repository.ErrProcessedImageNotFound) {
+			ip.Logger.Warn("Failed to look up processed image, processing it again",
+				zap.String("product_id", status.ProductID.String()),
+				zap.String("digest", status.Digest),
+				zap.Error(err))
+		}
+		return nil, nil, false
+	}
+
+	renditions, variants, err := decodeProcessedImage(processed)
+	if err != nil {
+		return nil, nil, false
+	}
+	keys, _ := processed.Keys()
+	if !ip.objectsExist(ctx, status, keys) {
+		return nil, nil, false
+	}
+
+	applyProcessedImage(status, processed)
+	ip.saveImageStatus(ctx, status)
+	imagesReused.Add(1)
+
+	return renditions, variants, true
+}
+
+// settleProcessedImage picks the processed image of a digest once this run
+// saved its own, as a concurrent run may have saved the digest first. The
+// stored image is kept if its objects all exist, and the objects of this run
+// are deleted; otherwise the stored image is replaced by this run's, unless
+// it changed meanwhile.
+func (ip *ImageProcessor) settleProcessedImage(ctx context.Context, status *model.ProductImageStatus, own, stored *model.ProcessedImage) (*model.ProcessedImage, error) {
+	ownKeys, err := own.Keys()
+	if err != nil {
+		return nil, err
+	}
+	storedKeys, err := stored.Keys()
+	if err == nil {
+		if slices.Equal(ownKeys, storedKeys) {
+			return own, nil
+		}
+		if ip.objectsExist(ctx, status, storedKeys) {
+			ip.deleteUnusedObjects(ctx, status, ownKeys, storedKeys)
+			return stored, nil
+		}
+	}
+
+	replaced, err := ip.ProcessedImageRepo.Replace(ctx, own, stored.Variants)
+	if err == nil && replaced {
+		return own, nil
+	}
+	ip.deleteUnusedObjects(ctx, status, ownKeys, storedKeys)
+	if err == nil {
+		// A retry reuses the image the concurrent run stored
+		err = errors.New("processed image changed concurrently")
+	}
+	return nil, err
+}
+
+// objectsExist reports whether the objects of a processed image all exist.
+func (ip *ImageProcessor) objectsExist(ctx context.Context, status *model.ProductImageStatus, keys []string) bool {
+	for _, key := range keys {
+		exists, err := ip.Store.Exists(ctx, key)
+		if err != nil || !exists {
+			ip.Logger.Warn("Processed image object is missing",
+				zap.String("product_id", status.ProductID.String()),
+				zap.String("digest", status.Digest),
+				zap.String("key", key),
+				zap.Error(err))
+			return false
+		}
+	}
+	return true
+}
+
+// deleteUnusedObjects deletes the objects this run uploaded that the stored
+// processed image does not use.
+func (ip *ImageProcessor) deleteUnusedObjects(ctx context.Context, status *model.ProductImageStatus, ownKeys, storedKeys []string) {
+	for _, key := range ownKeys {
+		if slices.Contains(storedKeys, key) {
+			continue
+		}
+		if err := ip.Store.Delete(ctx, key); err != nil {
+			ip.Logger.Warn("Failed to delete unused image object",
+				zap.String("product_id", status.ProductID.String()),
+				zap.String("key", key),
+				zap.Error(err))
+		}
+	}
+}
+
+// decodeProcessedImage returns the renditions and variants of a processed image.
+func decodeProcessedImage(processed *model.ProcessedImage) (map[string]string, []model.ImageVariant, error) {
+	var renditions map[string]string
+	var variants []model.ImageVariant
+	if err := json.Unmarshal(processed.Renditions, &renditions); err != nil {
+		return nil, nil, fmt.Errorf("invalid processed image renditions: %w", err)
+	}
+	if err := json.Unmarshal(processed.Variants, &variants); err != nil {
+		return nil, nil, fmt.Errorf("invalid processed image variants: %w", err)
+	}
+	return renditions, variants, nil
+}
+
+// applyProcessedImage marks a status uploaded with a processed image.
+func applyProcessedImage(status *model.ProductImageStatus, processed *model.ProcessedImage) {
+	status.Status = model.ImageStatusUploaded
+	status.Error = ""
+	status.ErrorClass = ""
+	status.Renditions = processed.Renditions
+	status.Variants = processed.Variants
+	status.OriginalWidth = processed.OriginalWidth
+	status.OriginalHeight = processed.OriginalHeight
+	status.OriginalFormat = processed.OriginalFormat
+	status.Metadata = processed.Metadata
+}
+
+// releaseProcessedImages drops the references of a product to the processed
+// images it no longer shows and deletes the objects of those no product
+// references anymore.
+func (ip *ImageProcessor) releaseProcessedImages(ctx context.Context, productID string, statuses []model.ProductImageStatus) {
+	var keep []string
+	for _, status := range statuses {
+		if status.Status == model.ImageStatusUploaded && status.Digest != "" {
+			keep = append(keep, status.Digest)
+		}
+	}
+	ip.releaseDigests(ctx, productID, keep)
+}
+
+// releaseUnusedProcessedImages releases the processed images a product
+// acquired that none of its stored image statuses holds, for tasks whose
+// results are not stored. The images of a newer task in flight are kept, as
+// it stores the digest of an image before acquiring it.
+func (ip *ImageProcessor) releaseUnusedProcessedImages(ctx context.Context, productID string) {
+	statuses, err := ip.ImageStatusRepo.GetByProductID(ctx, productID)
+	if err != nil {
+		ip.Logger.Error("Failed to load image statuses, not releasing processed images",
+			zap.String("product_id", productID),
+			zap.Error(err))
+		return
+	}
+	var keep []string
+	for _, status := range statuses {
+		if status.Digest != "" {
+			keep = append(keep, status.Digest)
+		}
+	}
+	ip.releaseDigests(ctx, productID, keep)
+}
+
+func (ip *ImageProcessor) releaseDigests(ctx context.Context, productID string, keep []string) {
+	released, err := ip.ProcessedImageRepo.Release(ctx, productID, keep)
+	if err != nil {
+		ip.Logger.Error("Failed to release processed images",
+			zap.String("product_id", productID),
+			zap.Error(err))
+		return
+	}
+	if err := storage.DeleteProcessedImages(ctx, ip.Store, released); err != nil {
+		ip.Logger.Warn("Failed to delete released image objects",
+			zap.String("product_id", productID),
+			zap.Error(err))
+	}
+}
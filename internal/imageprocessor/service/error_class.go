@@ -0,0 +1,113 @@
+// internal/imageprocessor/service/error_class.go
+
+package service
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+	"github.com/iSparshP/product-management-system/internal/imageprocessor/fetcher"
+)
+
+// classify labels err with its class, keeping the class of an error
+// classified already.
+func classify(class model.ErrorClass, err error) error {
+	var classified *model.ClassifiedError
+	if errors.As(err, &classified) {
+		return err
+	}
+	return &model.ClassifiedError{Class: class, Err: err}
+}
+
+// errorClass returns the class of an image processing error. Unclassified
+// deadline errors are timeouts wherever they happen.
+func errorClass(err error) model.ErrorClass {
+	var classified *model.ClassifiedError
+	switch {
+	case errors.As(err, &classified):
+		return classified.Class
+	case errors.Is(err, context.DeadlineExceeded):
+		return model.ErrorClassTimeout
+	}
+	return model.ErrorClassUnknown
+}
+
+// taskErrorClass returns the class shared by the errors of a task's images.
+func taskErrorClass(errs []error) model.ErrorClass {
+	var class model.ErrorClass
+	for _, err := range errs {
+		switch errClass := errorClass(err); {
+		case class == "":
+			class = errClass
+		case class != errClass:
+			return model.ErrorClassMixed
+		}
+	}
+	if class == "" {
+		return model.ErrorClassUnknown
+	}
+	return class
+}
+
+// fetchErrorClass classifies the errors of downloading a source image.
+func fetchErrorClass(err error) model.ErrorClass {
+	var statusErr *fetcher.StatusError
+	if errors.As(err, &statusErr) {
+		switch code := statusErr.StatusCode; {
+		case code == http.StatusNotFound || code == http.StatusGone:
+			return model.ErrorClassNotFound
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return model.ErrorClassForbidden
+		case code == http.StatusTooManyRequests:
+			return model.ErrorClassRateLimited
+		case code >= 500:
+			return model.ErrorClassUpstream5xx
+		default:
+			return model.ErrorClassInvalidSource
+		}
+	}
+
+	// Check the fetcher's own errors first: dial rejections come wrapped in
+	// network errors
+	var netErr net.Error
+	switch {
+	case errors.Is(err, fetcher.ErrSchemeNotAllowed), errors.Is(err, fetcher.ErrForbiddenAddress):
+		return model.ErrorClassForbidden
+	case errors.Is(err, fetcher.ErrInvalidURL), errors.Is(err, fetcher.ErrTooManyRedirects):
+		return model.ErrorClassInvalidSource
+	case errors.Is(err, fetcher.ErrTooLarge), errors.Is(err, fetcher.ErrTooManyPixels):
+		return model.ErrorClassTooLarge
+	case errors.Is(err, fetcher.ErrUnsupportedFormat):
+		return model.ErrorClassUnsupportedFormat
+	case errors.Is(err, fetcher.ErrCorruptImage):
+		return model.ErrorClassCorruptImage
+	case errors.Is(err, storage.ErrObjectNotFound):
+		// The upload the source key refers to was removed
+		return model.ErrorClassNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return model.ErrorClassTimeout
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return model.ErrorClassTimeout
+		}
+		return model.ErrorClassNetwork
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		// The connection dropped while reading the body
+		return model.ErrorClassNetwork
+	}
+	return model.ErrorClassUnknown
+}
+
+// decodeErrorClass classifies the errors of decoding a source image.
+func decodeErrorClass(err error) model.ErrorClass {
+	if errors.Is(err, image.ErrFormat) {
+		return model.ErrorClassUnsupportedFormat
+	}
+	return model.ErrorClassCorruptImage
+}
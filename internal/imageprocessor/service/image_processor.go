@@ -3,267 +3,798 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"image"
 	"io"
-	"net"
-	"net/http"
-	"os"
-	"syscall"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/disintegration/imaging"
+	"github.com/google/uuid"
 	"github.com/iSparshP/product-management-system/internal/domain/model"
 	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+	"github.com/iSparshP/product-management-system/internal/imageprocessor/fetcher"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/config"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/kafka"
-	"github.com/iSparshP/product-management-system/internal/infrastructure/s3"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/redis"
+	"github.com/iSparshP/product-management-system/pkg/imagemeta"
+	"github.com/iSparshP/product-management-system/pkg/utils"
+	"github.com/iSparshP/product-management-system/pkg/webp"
 	"go.uber.org/zap"
-)
+	"golang.org/x/sync/errgroup"
+	"gorm.io/datatypes"
 
-const (
-	maxRetries    = 3
-	retryInterval = 5 * time.Second
+	// Accept WebP source images
+	_ "golang.org/x/image/webp"
 )
 
-type ProcessError struct {
-	OriginalError error
-	RetryCount    int
-	TaskID        string
+type ImageProcessor struct {
+	Consumer *kafka.Consumer
+	// RetryConsumers consume the retry tiers, in the order of RetryDelays
+	RetryConsumers  []*kafka.Consumer
+	ProductRepo     repository.ProductRepository
+	ImageStatusRepo repository.ImageStatusRepository
+	DLQRepo         repository.DLQRepository
+	// ProcessedImageRepo shares the processed images between products
+	ProcessedImageRepo repository.ProcessedImageRepository
+	Store              storage.ObjectStore
+	Fetcher            *fetcher.Fetcher
+	RedisClient        *redis.Client
+	Logger             *zap.Logger
+	KafkaDLQ           *kafka.Publisher
+	// RetryDelays delay each retry tier; RetryPublishers publish to them
+	RetryDelays     []time.Duration
+	RetryPublishers []*kafka.Publisher
+	Renditions      []model.Rendition
+	// VariantFormats are produced for every rendition besides its own format
+	VariantFormats []model.ImageFormat
+	// ConvertToSRGB converts images with an embedded color profile to sRGB
+	ConvertToSRGB bool
+	// fingerprint identifies the settings above in the digests of images
+	fingerprint []byte
+	pool        *workerPool
 }
 
-func (e *ProcessError) Error() string {
-	return fmt.Sprintf("processing error after %d retries: %v", e.RetryCount, e.OriginalError)
+type imageEncoder func(w io.Writer, img image.Image, quality int) error
+
+// imageEncoders encode images in each output format the processor supports.
+// No pure-Go AVIF encoder is available, so AVIF variants are not produced yet.
+var imageEncoders = map[model.ImageFormat]imageEncoder{
+	model.ImageFormatJPEG: func(w io.Writer, img image.Image, quality int) error {
+		return imaging.Encode(w, img, imaging.JPEG, imaging.JPEGQuality(quality))
+	},
+	model.ImageFormatPNG: func(w io.Writer, img image.Image, _ int) error {
+		return imaging.Encode(w, img, imaging.PNG)
+	},
+	// The WebP encoder is lossless and has no quality setting
+	model.ImageFormatWebP: func(w io.Writer, img image.Image, _ int) error {
+		return webp.Encode(w, img)
+	},
 }
 
-type ImageProcessor struct {
-	Consumer    *kafka.Consumer
-	ProductRepo repository.ProductRepository
-	S3Client    *s3.Client
-	Logger      *zap.Logger
-	KafkaDLQ    *kafka.Publisher
-}
+func NewImageProcessor(consumer *kafka.Consumer, retryConsumers []*kafka.Consumer, repo repository.ProductRepository, imageStatusRepo repository.ImageStatusRepository, dlqRepo repository.DLQRepository, processedImageRepo repository.ProcessedImageRepository, store storage.ObjectStore, cfg *config.Config, logger *zap.Logger) *ImageProcessor {
+	dlqPublisher, err := kafka.NewPublisher(cfg.KafkaBrokers, model.ImageProcessingDLQTopic, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize Kafka DLQ publisher", zap.Error(err))
+	}
+	retryPublishers := make([]*kafka.Publisher, len(cfg.ImageRetryDelays))
+	for i, delay := range cfg.ImageRetryDelays {
+		retryPublishers[i] = dlqPublisher.WithTopic(model.ImageRetryTopic(delay))
+	}
+	redisClient := redis.NewRedisClient(cfg.RedisAddr)
 
-func NewImageProcessor(consumer *kafka.Consumer, repo repository.ProductRepository, cfg *config.Config, logger *zap.Logger) *ImageProcessor {
-	// Initialize S3 Client
-	s3Client := s3.NewS3Client(cfg.AWSAccessKey, cfg.AWSSecretKey, cfg.AWSRegion, cfg.AWSS3Bucket, cfg.AWSEndpoint, logger)
-	dlqPublisher, _ := kafka.NewPublisher(cfg.KafkaBrokers, "image_processing_dlq", logger)
+	var variantFormats []model.ImageFormat
+	for _, format := range cfg.ImageVariantFormats {
+		if _, ok := imageEncoders[format]; !ok {
+			logger.Warn("No encoder available for image format, skipping its variants",
+				zap.String("format", string(format)))
+			continue
+		}
+		variantFormats = append(variantFormats, format)
+	}
 
 	return &ImageProcessor{
-		Consumer:    consumer,
-		ProductRepo: repo,
-		S3Client:    s3Client,
-		Logger:      logger,
-		KafkaDLQ:    dlqPublisher,
+		Consumer:           consumer,
+		RetryConsumers:     retryConsumers,
+		ProductRepo:        repo,
+		ImageStatusRepo:    imageStatusRepo,
+		DLQRepo:            dlqRepo,
+		ProcessedImageRepo: processedImageRepo,
+		Store:              store,
+		Fetcher: fetcher.NewFetcher(fetcher.Config{
+			AllowedSchemes:       cfg.ImageFetchAllowedSchemes,
+			MaxRedirects:         cfg.ImageFetchMaxRedirects,
+			MaxBytes:             int64(cfg.ImageFetchMaxBytes),
+			MaxPixels:            int64(cfg.ImageFetchMaxPixels),
+			Timeout:              cfg.ImageFetchTimeout,
+			AllowPrivateNetworks: cfg.ImageFetchAllowPrivateNetworks,
+		}),
+		RedisClient:     redisClient,
+		Logger:          logger,
+		KafkaDLQ:        dlqPublisher,
+		RetryDelays:     cfg.ImageRetryDelays,
+		RetryPublishers: retryPublishers,
+		Renditions:      cfg.ImageRenditions,
+		VariantFormats:  variantFormats,
+		ConvertToSRGB:   cfg.ImageConvertToSRGB,
+		fingerprint:     processingFingerprint(cfg.ImageRenditions, variantFormats, cfg.ImageConvertToSRGB),
+		pool:            newWorkerPool(cfg.ImageWorkers, int64(cfg.ImageDecodeMemoryLimit)),
 	}
 }
 
+// Start consumes new tasks and the retry tiers until ctx is cancelled or a
+// consumer fails.
 func (ip *ImageProcessor) Start(ctx context.Context) error {
-	return ip.Consumer.Start(ctx, ip)
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error { return ip.Consumer.Start(ctx, ip) })
+	for _, consumer := range ip.RetryConsumers {
+		g.Go(func() error { return consumer.Start(ctx, ip) })
+	}
+	return g.Wait()
 }
 
-func (ip *ImageProcessor) ProcessImageTask(task model.ImageProcessingTask) error {
-	ip.Logger.Info("Processing image task", zap.String("product_id", task.ProductID))
+// ProcessImageTask makes one attempt at each image of the task. Images
+// failing with a transient error are retried by republishing the task to the
+// next retry tier; they only fail for good once the last tier is done.
+func (ip *ImageProcessor) ProcessImageTask(ctx context.Context, task model.ImageProcessingTask) error {
+	ip.Logger.Info("Processing image task",
+		zap.String("product_id", task.ProductID),
+		zap.Int("attempt", task.Attempt))
+
+	statuses := ip.initImageStatuses(ctx, task)
+	ip.setProcessingStatus(ctx, task, model.ImageProcessingInProgress)
+	canRetry := task.Attempt <= len(ip.RetryDelays)
+
+	// Renditions and variants of each source image, by position; nil for failed images
+	result := model.ImageProcessingResult{
+		CompressedImages: make([]map[string]string, len(statuses)),
+		Variants:         make([][]model.ImageVariant, len(statuses)),
+	}
+	errs := make([]error, len(statuses))
+
+	// Images are processed in parallel, as far as the worker pool allows
+	var wg sync.WaitGroup
+	for i := range statuses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := &statuses[i]
+			renditions, variants, err := ip.processImageAttempt(ctx, task, status)
+			if err != nil {
+				errs[i] = err
+				if ctx.Err() == nil {
+					class := errorClass(err)
+					imageFailures.Add(string(class), 1)
+					status.Status = model.ImageStatusFailed
+					if canRetry && class.Retryable() {
+						status.Status = model.ImageStatusRetrying
+					}
+					status.Error = err.Error()
+					status.ErrorClass = class
+					ip.saveImageStatus(ctx, status)
+				}
+				return
+			}
+			result.CompressedImages[i] = renditions
+			result.Variants[i] = variants
+		}()
+	}
+	wg.Wait()
+
+	// On rebalance or shutdown the task is redelivered; leave its status as is
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("image task interrupted: %w", err)
+	}
 
-	var compressedURLs []string
+	var compressedKeys, failedURLs []string
 	var processingErrors []error
-
-	for _, url := range task.ImageURLs {
-		processedURL, err := ip.processImageWithRetry(url, task.ProductID)
-		if err != nil {
-			processingErrors = append(processingErrors, err)
+	retrying := false
+	for i := range statuses {
+		if errs[i] != nil {
+			processingErrors = append(processingErrors, errs[i])
+			failedURLs = append(failedURLs, statuses[i].SourceURL)
+			retrying = retrying || statuses[i].Status == model.ImageStatusRetrying
 			continue
 		}
-		if processedURL != "" {
-			compressedURLs = append(compressedURLs, processedURL)
+		for _, variant := range result.Variants[i] {
+			compressedKeys = append(compressedKeys, variant.Ref())
+		}
+	}
+
+	if retrying {
+		cause := classify(taskErrorClass(processingErrors), fmt.Errorf("%v", processingErrors))
+		if err := ip.scheduleRetry(ctx, task, cause); err != nil {
+			ip.Logger.Error("Failed to schedule image task retry, failing its images",
+				zap.String("product_id", task.ProductID),
+				zap.Error(err))
+			ip.stopRetrying(ctx, statuses)
+			retrying = false
 		}
 	}
 
 	// Handle results
-	if len(compressedURLs) > 0 {
-		if err := ip.updateProductImages(task.ProductID, compressedURLs); err != nil {
-			// If update fails, send to DLQ for manual review
-			ip.sendToDLQ(task, err, compressedURLs)
+	if len(compressedKeys) > 0 {
+		result.Status = model.AggregateImageStatus(statuses)
+		if err := ip.updateProductImages(task.ProductID, task.ImageURLs, result); err != nil {
+			if errors.Is(err, repository.ErrSourceImagesChanged) {
+				// The product was edited or deleted meanwhile; a newer task owns its images
+				ip.Logger.Info("Discarding compressed images of a stale task",
+					zap.String("product_id", task.ProductID))
+				ip.releaseUnusedProcessedImages(ctx, task.ProductID)
+				return nil
+			}
+			err = classify(model.ErrorClassDBFailure, err)
+			// A retry stores the results again, reusing the uploaded images
+			if retrying || (canRetry && ip.scheduleRetry(ctx, task, err) == nil) {
+				return err
+			}
+			// If update fails for good, send to DLQ for manual review
+			ip.sendToDLQ(ctx, task, model.ErrorClassDBFailure, err, failedURLs, compressedKeys)
 			return err
 		}
 		ip.Logger.Info("Successfully updated product with compressed images",
 			zap.String("product_id", task.ProductID),
-			zap.Strings("compressed_urls", compressedURLs))
+			zap.Strings("compressed_keys", compressedKeys))
+		ip.releaseProcessedImages(ctx, task.ProductID, statuses)
+	}
+
+	if retrying {
+		return nil
 	}
 
 	// If we had any errors but also some successes, log warning
-	if len(processingErrors) > 0 && len(compressedURLs) > 0 {
+	if len(processingErrors) > 0 && len(compressedKeys) > 0 {
 		ip.Logger.Warn("Partial success processing images",
 			zap.String("product_id", task.ProductID),
-			zap.Int("success_count", len(compressedURLs)),
+			zap.Int("success_count", len(statuses)-len(processingErrors)),
 			zap.Int("error_count", len(processingErrors)))
 	}
 
 	// If all images failed, return error
 	if len(processingErrors) == len(task.ImageURLs) {
+		ip.setProcessingStatus(ctx, task, model.ImageProcessingFailed)
+		ip.releaseUnusedProcessedImages(ctx, task.ProductID)
 		err := fmt.Errorf("all images failed to process: %v", processingErrors)
-		ip.sendToDLQ(task, err, nil)
+		ip.sendToDLQ(ctx, task, taskErrorClass(processingErrors), err, failedURLs, nil)
 		return err
 	}
 
 	return nil
 }
 
-func (ip *ImageProcessor) processImageWithRetry(url, productID string) (string, error) {
-	var lastErr error
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		s3URL, err := ip.processImage(url)
-		if err == nil {
-			return s3URL, nil
+// initImageStatuses loads the status of every image of the task. Status
+// tracking is best effort: if it cannot be stored, images are still processed.
+func (ip *ImageProcessor) initImageStatuses(ctx context.Context, task model.ImageProcessingTask) []model.ProductImageStatus {
+	statuses, err := ip.ImageStatusRepo.Init(ctx, task.ProductID, task.ImageURLs)
+	if err == nil && len(statuses) == len(task.ImageURLs) {
+		return statuses
+	}
+	ip.Logger.Warn("Failed to initialize image statuses",
+		zap.String("product_id", task.ProductID),
+		zap.Error(err))
+
+	productID, _ := uuid.Parse(task.ProductID)
+	statuses = make([]model.ProductImageStatus, len(task.ImageURLs))
+	for position, url := range task.ImageURLs {
+		statuses[position] = model.ProductImageStatus{
+			ProductID:  productID,
+			Position:   position,
+			SourceURL:  url,
+			Status:     model.ImageStatusPending,
+			Renditions: utils.StringMapToJSON(nil),
+			Variants:   datatypes.JSON("[]"),
+			Metadata:   utils.StringMapToJSON(nil),
 		}
+	}
+	return statuses
+}
 
-		lastErr = err
-		ip.Logger.Warn("Image processing attempt failed",
-			zap.String("url", url),
-			zap.String("product_id", productID),
-			zap.Int("attempt", attempt),
+func (ip *ImageProcessor) saveImageStatus(ctx context.Context, status *model.ProductImageStatus) {
+	if err := ip.ImageStatusRepo.Save(ctx, status); err != nil {
+		ip.Logger.Warn("Failed to save image status",
+			zap.String("product_id", status.ProductID.String()),
+			zap.Int("position", status.Position),
+			zap.String("status", string(status.Status)),
 			zap.Error(err))
+	}
+}
+
+// setProcessingStatus updates the aggregate status shown on the product.
+func (ip *ImageProcessor) setProcessingStatus(ctx context.Context, task model.ImageProcessingTask, status model.ImageProcessingStatus) {
+	err := ip.ProductRepo.UpdateImageProcessingStatus(ctx, task.ProductID, task.ImageURLs, status)
+	switch {
+	case err == nil:
+		ip.invalidateProductCache(ctx, task.ProductID)
+	case errors.Is(err, repository.ErrSourceImagesChanged):
+		// A newer task reports the status of the current images
+	default:
+		ip.Logger.Warn("Failed to update image processing status",
+			zap.String("product_id", task.ProductID),
+			zap.String("status", string(status)),
+			zap.Error(err))
+	}
+}
 
-		if !ip.isRetryableError(err) {
-			return "", err
+// processImageAttempt makes one attempt at an image, holding a worker. A
+// retried task reuses the images an earlier attempt uploaded and does not
+// retry those that failed for good; a replay asking for failed images only
+// reuses the uploaded ones too.
+func (ip *ImageProcessor) processImageAttempt(ctx context.Context, task model.ImageProcessingTask, status *model.ProductImageStatus) (map[string]string, []model.ImageVariant, error) {
+	if (task.Attempt > 1 || task.OnlyFailed) && status.Status == model.ImageStatusUploaded {
+		var renditions map[string]string
+		var variants []model.ImageVariant
+		if json.Unmarshal(status.Renditions, &renditions) == nil && len(renditions) > 0 &&
+			json.Unmarshal(status.Variants, &variants) == nil {
+			return renditions, variants, nil
 		}
+	}
+	if task.Attempt > 1 && status.Status == model.ImageStatusFailed {
+		return nil, nil, &model.ClassifiedError{Class: status.ErrorClass, Err: errors.New(status.Error)}
+	}
+
+	releaseWorker, err := ip.pool.acquireWorker(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer releaseWorker()
 
-		if attempt < maxRetries {
-			time.Sleep(retryInterval * time.Duration(attempt))
+	status.Attempts++
+	renditions, variants, err := ip.processImage(ctx, status)
+	switch {
+	case err == nil:
+		imagesProcessed.Add(1)
+	case ctx.Err() == nil:
+		ip.Logger.Warn("Image processing attempt failed",
+			zap.String("url", status.SourceURL),
+			zap.String("product_id", status.ProductID.String()),
+			zap.Int("attempt", task.Attempt),
+			zap.String("error_class", string(errorClass(err))),
+			zap.Error(err))
+	}
+	return renditions, variants, err
+}
+
+// scheduleRetry republishes the task to the retry tier following its attempt,
+// due once the tier's delay has passed.
+func (ip *ImageProcessor) scheduleRetry(ctx context.Context, task model.ImageProcessingTask, cause error) error {
+	tier := task.Attempt - 1
+	payload, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	notBefore := time.Now().Add(ip.RetryDelays[tier]).UTC()
+	headers := map[string]string{
+		kafka.HeaderAttempt:   strconv.Itoa(task.Attempt + 1),
+		kafka.HeaderNotBefore: notBefore.Format(time.RFC3339Nano),
+	}
+	publisher := ip.RetryPublishers[tier]
+	if err := publisher.PublishWithHeaders(ctx, task.ProductID, payload, headers); err != nil {
+		return err
+	}
+
+	taskRetries.Add(string(errorClass(cause)), 1)
+	ip.Logger.Info("Scheduled image task retry",
+		zap.String("product_id", task.ProductID),
+		zap.Int("attempt", task.Attempt+1),
+		zap.String("topic", publisher.Topic()),
+		zap.Time("not_before", notBefore),
+		zap.NamedError("cause", cause))
+	return nil
+}
+
+// stopRetrying fails the images left waiting for a retry that could not be
+// scheduled.
+func (ip *ImageProcessor) stopRetrying(ctx context.Context, statuses []model.ProductImageStatus) {
+	for i := range statuses {
+		if statuses[i].Status == model.ImageStatusRetrying {
+			statuses[i].Status = model.ImageStatusFailed
+			ip.saveImageStatus(ctx, &statuses[i])
 		}
 	}
+}
+
+// fetchSource returns the content of a source image, reading uploaded images
+// from the object store and downloading the others.
+func (ip *ImageProcessor) fetchSource(ctx context.Context, source string) ([]byte, error) {
+	if !model.IsUploadKey(source) {
+		return ip.Fetcher.Fetch(ctx, source)
+	}
 
-	return "", &ProcessError{
-		OriginalError: lastErr,
-		RetryCount:    maxRetries,
-		TaskID:        productID,
+	body, _, err := ip.Store.Get(ctx, source)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, err
+		}
+		return nil, classify(model.ErrorClassStorageFailure, err)
 	}
+	defer body.Close()
+	return ip.Fetcher.Read(body)
 }
 
-func (ip *ImageProcessor) processImage(url string) (string, error) {
+// processImage produces every configured rendition of one image in each
+// output format. It returns the URL of each rendition in its primary format,
+// by rendition name, and all variants, recording each stage in its status.
+func (ip *ImageProcessor) processImage(ctx context.Context, status *model.ProductImageStatus) (map[string]string, []model.ImageVariant, error) {
+	status.Status = model.ImageStatusDownloading
+	ip.saveImageStatus(ctx, status)
+
 	// Download Image
-	resp, err := ip.downloadImage(url)
+	data, err := ip.fetchSource(ctx, status.SourceURL)
+	if err != nil {
+		return nil, nil, classify(fetchErrorClass(err), fmt.Errorf("download failed: %w", err))
+	}
+
+	// Identical images are processed once and shared. The digest is stored
+	// first, so that a stale task releasing the images of the product keeps it
+	status.Digest = ip.imageDigest(data)
+	ip.saveImageStatus(ctx, status)
+	if renditions, variants, ok := ip.reuseProcessedImage(ctx, status); ok {
+		return renditions, variants, nil
+	}
+
+	status.Status = model.ImageStatusProcessing
+	ip.saveImageStatus(ctx, status)
+
+	// The decoded image is kept until all its renditions are uploaded
+	releaseMemory, err := ip.pool.acquireDecodeMemory(ctx, data)
 	if err != nil {
-		return "", fmt.Errorf("download failed: %w", err)
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
+	defer releaseMemory()
 
-	// Decode and process image
-	img, err := ip.decodeAndCompressImage(resp.Body)
+	// Decode once, then resize for each rendition
+	src, err := ip.decodeImage(data, status)
 	if err != nil {
-		return "", fmt.Errorf("processing failed: %w", err)
+		return nil, nil, err
 	}
+	opaque := isOpaque(src)
 
-	// Save to temporary file
-	tempFile, err := ip.saveToTempFile(img)
+	renditions := make(map[string]string, len(ip.Renditions))
+	var variants []model.ImageVariant
+	for _, rendition := range ip.Renditions {
+		renditionVariants, err := ip.processRendition(ctx, src, rendition, opaque, status)
+		if err != nil {
+			return nil, nil, fmt.Errorf("rendition %s: %w", rendition.Name, err)
+		}
+		renditions[rendition.Name] = renditionVariants[0].Key
+		variants = append(variants, renditionVariants...)
+	}
+
+	variantsJSON, err := json.Marshal(variants)
 	if err != nil {
-		return "", fmt.Errorf("save failed: %w", err)
+		return nil, nil, fmt.Errorf("failed to marshal variants: %w", err)
 	}
-	defer os.Remove(tempFile.Name())
 
-	// Upload to S3
-	s3URL, err := ip.uploadToS3(tempFile)
+	own := &model.ProcessedImage{
+		Digest:         status.Digest,
+		Renditions:     utils.StringMapToJSON(renditions),
+		Variants:       variantsJSON,
+		OriginalWidth:  status.OriginalWidth,
+		OriginalHeight: status.OriginalHeight,
+		OriginalFormat: status.OriginalFormat,
+		Metadata:       status.Metadata,
+	}
+	stored, err := ip.ProcessedImageRepo.Save(ctx, own, status.ProductID.String())
+	if err == nil {
+		stored, err = ip.settleProcessedImage(ctx, status, own, stored)
+	}
 	if err != nil {
-		return "", fmt.Errorf("upload failed: %w", err)
+		return nil, nil, classify(model.ErrorClassDBFailure, fmt.Errorf("failed to record processed image: %w", err))
+	}
+	if stored != own {
+		if renditions, variants, err = decodeProcessedImage(stored); err != nil {
+			return nil, nil, classify(model.ErrorClassDBFailure, err)
+		}
 	}
 
-	return s3URL, nil
+	applyProcessedImage(status, stored)
+	ip.saveImageStatus(ctx, status)
+
+	return renditions, variants, nil
 }
 
-func (ip *ImageProcessor) isRetryableError(err error) bool {
-	if err == nil {
-		return false
+// decodeImage decodes a source image, turns it upright according to its EXIF
+// orientation and converts it to sRGB if it embeds a color profile. Its
+// dimensions and the metadata worth keeping are recorded in the status; the
+// renditions are encoded from the pixels alone, so no EXIF, XMP or ICC data
+// of the source reaches them.
+func (ip *ImageProcessor) decodeImage(data []byte, status *model.ProductImageStatus) (image.Image, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, classify(decodeErrorClass(err), fmt.Errorf("processing failed: %w", err))
 	}
 
-	// Check for specific error types that should be retried
-	switch {
-	case errors.Is(err, context.DeadlineExceeded),
-		errors.Is(err, io.ErrUnexpectedEOF),
-		errors.Is(err, syscall.ECONNRESET),
-		errors.Is(err, syscall.ETIMEDOUT):
-		return true
+	meta := imagemeta.Read(data)
+	kept := model.ImageMetadata{
+		Orientation: meta.Orientation,
+		CameraMake:  meta.Make,
+		CameraModel: meta.Model,
+		CapturedAt:  meta.CapturedAt,
+	}
+	img = orient(img, meta.Orientation)
+	if len(meta.ICCProfile) > 0 {
+		img, kept.ColorProfile, kept.ConvertedToSRGB = ip.convertToSRGB(img, meta.ICCProfile, status)
+	}
+
+	metadataJSON, err := json.Marshal(kept)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
 	}
+	bounds := img.Bounds()
+	status.OriginalWidth = bounds.Dx()
+	status.OriginalHeight = bounds.Dy()
+	status.OriginalFormat = format
+	status.Metadata = metadataJSON
+
+	return img, nil
+}
+
+// orient applies an EXIF orientation so the image displays upright.
+func orient(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	default:
+		return img
+	}
+}
+
+// convertToSRGB converts the image from its embedded color profile, which
+// the renditions do not carry. Images whose profile cannot be converted are
+// kept as they are. It returns the image, the profile description and
+// whether colors were converted.
+func (ip *ImageProcessor) convertToSRGB(img image.Image, iccProfile []byte, status *model.ProductImageStatus) (image.Image, string, bool) {
+	profile, err := imagemeta.ParseICCProfile(iccProfile)
+	if err != nil {
+		ip.Logger.Warn("Ignoring invalid color profile",
+			zap.String("product_id", status.ProductID.String()),
+			zap.Int("position", status.Position),
+			zap.Error(err))
+		return img, "", false
+	}
+	if !ip.ConvertToSRGB || profile.IsSRGB() {
+		return img, profile.Description, false
+	}
+
+	converted, err := profile.ConvertToSRGB(img)
+	if err != nil {
+		ip.Logger.Warn("Color profile cannot be converted to sRGB, keeping colors as they are",
+			zap.String("product_id", status.ProductID.String()),
+			zap.Int("position", status.Position),
+			zap.String("color_profile", profile.Description),
+			zap.String("color_space", profile.ColorSpace),
+			zap.Error(err))
+		return img, profile.Description, false
+	}
+	return converted, profile.Description, true
+}
+
+// processRendition resizes the image for a rendition and uploads it in each
+// of its formats.
+func (ip *ImageProcessor) processRendition(ctx context.Context, src image.Image, rendition model.Rendition, opaque bool, status *model.ProductImageStatus) ([]model.ImageVariant, error) {
+	img := resizeForRendition(src, rendition)
+	bounds := img.Bounds()
+
+	formats := renditionFormats(rendition.Format, ip.VariantFormats, opaque)
+	variants := make([]model.ImageVariant, 0, len(formats))
+	for _, format := range formats {
+		key := model.ProcessedImageKey(status.ProductID, status.Digest, rendition.Name, format)
+		if err := ip.uploadVariant(ctx, img, rendition, format, key); err != nil {
+			return nil, err
+		}
 
-	// Check for network-related errors
-	if netErr, ok := err.(net.Error); ok {
-		return netErr.Temporary() || netErr.Timeout()
+		variants = append(variants, model.ImageVariant{
+			Rendition: rendition.Name,
+			Format:    format,
+			Key:       key,
+			Width:     bounds.Dx(),
+			Height:    bounds.Dy(),
+		})
 	}
 
-	// Add more specific error checks as needed
-	return false
+	return variants, nil
 }
 
-func (ip *ImageProcessor) sendToDLQ(task model.ImageProcessingTask, err error, partialResults []string) {
+// renditionFormats returns the rendition format followed by the variant
+// formats. JPEG cannot hold alpha, so transparent images use PNG instead.
+// Lossless variants of a rendition that has a JPEG are skipped, as they are
+// never served.
+func renditionFormats(format model.ImageFormat, variantFormats []model.ImageFormat, opaque bool) []model.ImageFormat {
+	primary := format
+	if primary == model.ImageFormatJPEG && !opaque {
+		primary = model.ImageFormatPNG
+	}
+	formats := []model.ImageFormat{primary}
+	for _, format := range variantFormats {
+		if format == model.ImageFormatJPEG && !opaque {
+			continue
+		}
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	if slices.Contains(formats, model.ImageFormatJPEG) {
+		formats = slices.DeleteFunc(formats, func(format model.ImageFormat) bool {
+			return format != primary && format.Lossless()
+		})
+	}
+	return formats
+}
+
+// isOpaque reports whether the image has no transparent pixels.
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return true
+}
+
+// sendToDLQ publishes a task that failed for good to the DLQ topic and
+// records it in the DLQ entries for review.
+func (ip *ImageProcessor) sendToDLQ(ctx context.Context, task model.ImageProcessingTask, errorClass model.ErrorClass, err error, failedURLs, partialResults []string) {
 	dlqMessage := model.DLQMessage{
+		ID:             uuid.NewString(),
 		TaskID:         task.ProductID,
 		OriginalTask:   task,
+		ErrorClass:     errorClass,
 		Error:          err.Error(),
+		FailedURLs:     failedURLs,
 		PartialResults: partialResults,
 		Timestamp:      time.Now(),
-		RetryCount:     maxRetries,
+		RetryCount:     task.Attempt - 1,
 	}
+	dlqTasks.Add(string(errorClass), 1)
 
-	if err := ip.publishToDLQ(dlqMessage); err != nil {
+	if err := ip.publishToDLQ(ctx, dlqMessage); err != nil {
 		ip.Logger.Error("Failed to publish to DLQ",
 			zap.String("product_id", task.ProductID),
 			zap.Error(err))
 	}
+	if err := ip.recordDLQEntry(ctx, dlqMessage); err != nil {
+		ip.Logger.Error("Failed to record DLQ entry",
+			zap.String("product_id", task.ProductID),
+			zap.Error(err))
+	}
 }
 
-func (ip *ImageProcessor) publishToDLQ(message model.DLQMessage) error {
-	// Implement DLQ publishing logic here
-	// This could be a separate Kafka topic, database table, or other storage
+func (ip *ImageProcessor) publishToDLQ(ctx context.Context, message model.DLQMessage) error {
 	messageBytes, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal DLQ message: %w", err)
 	}
 
-	// Example using Kafka DLQ topic
-	if err := ip.KafkaDLQ.Publish(context.Background(), messageBytes); err != nil {
+	if err := ip.KafkaDLQ.PublishWithKey(ctx, message.TaskID, messageBytes); err != nil {
 		return fmt.Errorf("failed to publish to DLQ: %w", err)
 	}
 
 	return nil
 }
 
-func (ip *ImageProcessor) updateProductImages(productID string, compressedURLs []string) error {
-	ctx := context.Background()
-	return ip.ProductRepo.UpdateCompressedImages(ctx, productID, compressedURLs)
+func (ip *ImageProcessor) recordDLQEntry(ctx context.Context, message model.DLQMessage) error {
+	task, err := json.Marshal(message.OriginalTask)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+	productID, _ := uuid.Parse(message.OriginalTask.ProductID)
+
+	return ip.DLQRepo.Create(ctx, &model.DLQEntry{
+		// The message carries the entry id, so backfills know it is recorded
+		ID:         uuid.MustParse(message.ID),
+		ProductID:  productID,
+		ErrorClass: message.ErrorClass,
+		Error:      message.Error,
+		// Empty lists are stored as [] rather than null
+		FailedURLs:     utils.StringSliceToJSON(append([]string{}, message.FailedURLs...)),
+		PartialResults: utils.StringSliceToJSON(append([]string{}, message.PartialResults...)),
+		Attempts:       message.OriginalTask.Attempt,
+		Task:           task,
+		Status:         model.DLQStatusOpen,
+		FailedAt:       message.Timestamp,
+	})
 }
 
-func (ip *ImageProcessor) saveToTempFile(img image.Image) (*os.File, error) {
-	tempFile, err := os.CreateTemp("", "compressed-*.jpg")
-	if err != nil {
-		return nil, err
+func (ip *ImageProcessor) updateProductImages(productID string, sourceURLs []string, result model.ImageProcessingResult) error {
+	ctx := context.Background()
+	if err := ip.ProductRepo.UpdateCompressedImages(ctx, productID, sourceURLs, result); err != nil {
+		return err
 	}
 
-	if err := imaging.Save(img, tempFile.Name(), imaging.JPEGQuality(80)); err != nil {
-		tempFile.Close()
-		os.Remove(tempFile.Name())
-		return nil, err
+	ip.invalidateProductCache(ctx, productID)
+	return nil
+}
+
+// invalidateProductCache drops the cached product after a write bumped its
+// version, so the cached copy and its ETag are stale.
+func (ip *ImageProcessor) invalidateProductCache(ctx context.Context, productID string) {
+	if err := ip.RedisClient.Del(ctx, model.ProductCacheKey(productID)).Err(); err != nil {
+		ip.Logger.Warn("Failed to invalidate product cache",
+			zap.Error(err),
+			zap.String("product_id", productID))
 	}
+}
 
-	return tempFile, nil
+// resizeForRendition sizes the source image to the rendition box. Images are
+// never scaled up, except by fill to cover the whole box.
+func resizeForRendition(src image.Image, rendition model.Rendition) image.Image {
+	switch rendition.Mode {
+	case model.RenditionFill:
+		return imaging.Fill(src, rendition.Width, rendition.Height, imaging.Center, imaging.Lanczos)
+	case model.RenditionCrop:
+		return imaging.CropCenter(src, rendition.Width, rendition.Height)
+	default:
+		return imaging.Fit(src, rendition.Width, rendition.Height, imaging.Lanczos)
+	}
 }
 
-func (ip *ImageProcessor) decodeAndCompressImage(r io.Reader) (image.Image, error) {
-	img, err := imaging.Decode(r)
-	if err != nil {
-		return nil, err
+// uploadVariant encodes a rendition in format and streams it to the object
+// store under key, without buffering the encoded image.
+func (ip *ImageProcessor) uploadVariant(ctx context.Context, img image.Image, rendition model.Rendition, format model.ImageFormat, key string) error {
+	encode, ok := imageEncoders[format]
+	if !ok {
+		return classify(model.ErrorClassUnknown, fmt.Errorf("no encoder for format %s", format))
 	}
 
-	return imaging.Fit(img, 1200, 1200, imaging.Lanczos), nil
+	pr, pw := io.Pipe()
+	w := &recordingWriter{w: pw}
+	encodeErr := make(chan error, 1)
+	go func() {
+		err := encode(w, img, rendition.Quality)
+		// A nil error ends the upload's body; others fail the upload
+		pw.CloseWithError(err)
+		encodeErr <- err
+	}()
+
+	putErr := ip.Store.Put(ctx, key, pr, format.MimeType())
+	// Unblock the encoder if the upload stopped reading early
+	pr.CloseWithError(errUploadStopped)
+	err := <-encodeErr
+
+	switch {
+	case err != nil && w.err == nil:
+		return classify(model.ErrorClassUnknown, fmt.Errorf("encode %s failed: %w", format, err))
+	case putErr != nil:
+		return classify(model.ErrorClassStorageFailure, fmt.Errorf("upload %s failed: %w", format, putErr))
+	case err != nil:
+		// The store returned before reading the whole image
+		return classify(model.ErrorClassStorageFailure, fmt.Errorf("upload %s failed: %w", format, err))
+	}
+	return nil
 }
 
-func (ip *ImageProcessor) uploadToS3(file *os.File) (string, error) {
-	ctx := context.Background()
-	return ip.S3Client.UploadFile(ctx, file.Name(), file.Name())
+// errUploadStopped fails the encoder's writes once the upload has returned.
+var errUploadStopped = errors.New("upload stopped reading")
+
+// recordingWriter records the first failed write, telling write failures
+// from encoding failures.
+type recordingWriter struct {
+	w   io.Writer
+	err error
 }
 
-func (ip *ImageProcessor) downloadImage(url string) (*http.Response, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
-	return client.Get(url)
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
 }
@@ -0,0 +1,41 @@
+// internal/imageprocessor/service/image_processor_test.go
+
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+)
+
+func TestRenditionFormats(t *testing.T) {
+	const (
+		jpeg = model.ImageFormatJPEG
+		png  = model.ImageFormatPNG
+		webp = model.ImageFormatWebP
+		avif = model.ImageFormatAVIF
+	)
+	tests := []struct {
+		name     string
+		format   model.ImageFormat
+		variants []model.ImageFormat
+		opaque   bool
+		want     []model.ImageFormat
+	}{
+		{"opaque JPEG skips lossless WebP", jpeg, []model.ImageFormat{webp}, true, []model.ImageFormat{jpeg}},
+		{"opaque JPEG keeps lossy variants", jpeg, []model.ImageFormat{webp, avif}, true, []model.ImageFormat{jpeg, avif}},
+		{"transparent JPEG becomes PNG with WebP", jpeg, []model.ImageFormat{webp}, false, []model.ImageFormat{png, webp}},
+		{"transparent images get no JPEG", png, []model.ImageFormat{jpeg, webp}, false, []model.ImageFormat{png, webp}},
+		{"lossless rendition format is kept", png, []model.ImageFormat{jpeg, webp}, true, []model.ImageFormat{png, jpeg}},
+		{"duplicates", webp, []model.ImageFormat{webp}, true, []model.ImageFormat{webp}},
+		{"no variants", jpeg, nil, true, []model.ImageFormat{jpeg}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renditionFormats(tt.format, tt.variants, tt.opaque); !slices.Equal(got, tt.want) {
+				t.Errorf("renditionFormats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
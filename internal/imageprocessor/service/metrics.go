@@ -0,0 +1,15 @@
+// internal/imageprocessor/service/metrics.go
+
+package service
+
+import "expvar"
+
+// Processor metrics, published with expvar; maps count by error class
+var (
+	imagesProcessed = expvar.NewInt("image_processor_images_processed")
+	// imagesReused counts the images whose processed image was shared
+	imagesReused  = expvar.NewInt("image_processor_images_reused")
+	imageFailures = expvar.NewMap("image_processor_image_failures")
+	taskRetries   = expvar.NewMap("image_processor_task_retries")
+	dlqTasks      = expvar.NewMap("image_processor_dlq_tasks")
+)
@@ -0,0 +1,63 @@
+// internal/imageprocessor/service/worker_pool.go
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// decodedBytesPerPixel estimates the memory taken by each source pixel: the
+// decoded image, plus the copy made to turn it upright or convert its colors.
+const decodedBytesPerPixel = 2 * 4
+
+// workerPool is shared by the tasks of every partition. It bounds the images
+// processed at once and the memory their decoded pixels take.
+type workerPool struct {
+	workers     *semaphore.Weighted
+	memory      *semaphore.Weighted
+	memoryLimit int64
+}
+
+func newWorkerPool(workers int, memoryLimit int64) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if memoryLimit < 1 {
+		memoryLimit = 1
+	}
+	return &workerPool{
+		workers:     semaphore.NewWeighted(int64(workers)),
+		memory:      semaphore.NewWeighted(memoryLimit),
+		memoryLimit: memoryLimit,
+	}
+}
+
+// acquireWorker waits for a free worker. The returned function releases it.
+func (p *workerPool) acquireWorker(ctx context.Context) (func(), error) {
+	if err := p.workers.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	return func() { p.workers.Release(1) }, nil
+}
+
+// acquireDecodeMemory waits until the image in data can be decoded within the
+// memory limit. An image larger than the limit waits for all the memory and
+// is then decoded alone. The returned function releases the memory.
+func (p *workerPool) acquireDecodeMemory(ctx context.Context, data []byte) (func(), error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// Classified like the decode that would otherwise fail next
+		return nil, classify(decodeErrorClass(err), fmt.Errorf("processing failed: %w", err))
+	}
+
+	weight := min(int64(cfg.Width)*int64(cfg.Height)*decodedBytesPerPixel, p.memoryLimit)
+	if err := p.memory.Acquire(ctx, weight); err != nil {
+		return nil, err
+	}
+	return func() { p.memory.Release(weight) }, nil
+}
@@ -0,0 +1,45 @@
+// internal/imageprocessor/service/worker_pool_test.go
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+)
+
+func TestAcquireDecodeMemory(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	valid := buf.Bytes()
+
+	tests := []struct {
+		name      string
+		data      []byte
+		wantClass model.ErrorClass
+	}{
+		{"corrupt header", valid[:20], model.ErrorClassCorruptImage},
+		{"unknown format", []byte("<html>not an image</html>"), model.ErrorClassUnsupportedFormat},
+	}
+	pool := newWorkerPool(1, 1<<20)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pool.acquireDecodeMemory(context.Background(), tt.data)
+			if got := errorClass(err); got != tt.wantClass {
+				t.Errorf("acquireDecodeMemory() error class = %s, want %s", got, tt.wantClass)
+			}
+		})
+	}
+
+	release, err := pool.acquireDecodeMemory(context.Background(), valid)
+	if err != nil {
+		t.Fatalf("acquireDecodeMemory() error = %v", err)
+	}
+	release()
+}
@@ -5,10 +5,14 @@ package config
 import (
 	"log"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
 )
 
 type Config struct {
@@ -25,7 +29,59 @@ type Config struct {
 	AWSS3Bucket      string
 	AWSRegion        string
 	AWSEndpoint      string
-	LogLevel         string
+	// AWSS3PathStyle addresses the bucket by path rather than by virtual host
+	AWSS3PathStyle bool
+	LogLevel       string
+	DBAutoMigrate  bool
+
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	// GCGracePeriod is the age under which unreferenced objects are kept
+	GCGracePeriod time.Duration
+
+	OutboxPollInterval time.Duration
+	OutboxRetention    time.Duration
+
+	ImageRenditions     []model.Rendition
+	ImageVariantFormats []model.ImageFormat
+	ImageConvertToSRGB  bool
+
+	ImageFetchAllowedSchemes       []string
+	ImageFetchMaxRedirects         int
+	ImageFetchMaxBytes             int
+	ImageFetchMaxPixels            int
+	ImageFetchTimeout              time.Duration
+	ImageFetchAllowPrivateNetworks bool
+
+	ImageWorkers           int
+	ImageDecodeMemoryLimit int
+
+	ImageRetryDelays []time.Duration
+
+	AdminToken string
+
+	// StorageDriver selects the object store: s3 or local
+	StorageDriver string
+	// StorageLocalDir is the root directory of the local store
+	StorageLocalDir string
+	// StoragePublicURL is where the API serves local store objects
+	StoragePublicURL string
+	// StorageSigningKey signs presigned URLs of the stores served by the API
+	StorageSigningKey string
+	// StorageCDNURL replaces the store's base URL in the URLs of objects
+	StorageCDNURL string
+	// StorageSignedURLs serves objects through presigned URLs expiring after
+	// StorageSignedURLExpiry
+	StorageSignedURLs      bool
+	StorageSignedURLExpiry time.Duration
+
+	// UploadMaxBytes bounds the images uploaded through the API
+	UploadMaxBytes int
+	// UploadURLExpiry is how long presigned upload URLs stay valid
+	UploadURLExpiry time.Duration
+
+	// MetricsAddr is where the image processor serves its metrics; empty disables them
+	MetricsAddr string
 }
 
 // LoadConfig loads configuration from environment variables.
@@ -72,12 +128,53 @@ func LoadConfig() *Config {
 		AWSSecretKey:     os.Getenv("AWS_SECRET_ACCESS_KEY"),
 		AWSS3Bucket:      os.Getenv("AWS_S3_BUCKET"),
 		AWSRegion:        os.Getenv("AWS_REGION"),
-		AWSEndpoint:      getEnvOrDefault("AWS_ENDPOINT", "https://s3.amazonaws.com"),
+		AWSEndpoint:      os.Getenv("AWS_ENDPOINT"),
+		AWSS3PathStyle:   getEnvAsBoolOrDefault("AWS_S3_PATH_STYLE", false),
 		LogLevel:         getEnvOrDefault("LOG_LEVEL", "info"),
+		DBAutoMigrate:    getEnvAsBoolOrDefault("DB_AUTO_MIGRATE", false),
+
+		TrashRetention:     getEnvAsDurationOrDefault("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: getEnvAsDurationOrDefault("TRASH_PURGE_INTERVAL", time.Hour),
+		GCGracePeriod:      getEnvAsDurationOrDefault("GC_GRACE_PERIOD", 24*time.Hour),
+
+		OutboxPollInterval: getEnvAsDurationOrDefault("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetention:    getEnvAsDurationOrDefault("OUTBOX_RETENTION", 24*time.Hour),
+
+		ImageRenditions:     getEnvAsRenditionsOrDefault("IMAGE_RENDITIONS", model.DefaultImageRenditions),
+		ImageVariantFormats: getEnvAsImageFormatsOrDefault("IMAGE_VARIANT_FORMATS", model.DefaultImageVariantFormats),
+		ImageConvertToSRGB:  getEnvAsBoolOrDefault("IMAGE_CONVERT_TO_SRGB", true),
+
+		ImageFetchAllowedSchemes:       splitAndTrim(strings.ToLower(getEnvOrDefault("IMAGE_FETCH_ALLOWED_SCHEMES", "https,http")), ","),
+		ImageFetchMaxRedirects:         getEnvAsIntOrDefault("IMAGE_FETCH_MAX_REDIRECTS", 3),
+		ImageFetchMaxBytes:             getEnvAsIntOrDefault("IMAGE_FETCH_MAX_BYTES", 25<<20),
+		ImageFetchMaxPixels:            getEnvAsIntOrDefault("IMAGE_FETCH_MAX_PIXELS", 50_000_000),
+		ImageFetchTimeout:              getEnvAsDurationOrDefault("IMAGE_FETCH_TIMEOUT", 30*time.Second),
+		ImageFetchAllowPrivateNetworks: getEnvAsBoolOrDefault("IMAGE_FETCH_ALLOW_PRIVATE_NETWORKS", false),
+
+		ImageWorkers:           getEnvAsIntOrDefault("IMAGE_WORKERS", runtime.NumCPU()),
+		ImageDecodeMemoryLimit: getEnvAsIntOrDefault("IMAGE_DECODE_MEMORY_LIMIT", 1<<30),
+
+		ImageRetryDelays: getEnvAsDurationsOrDefault("IMAGE_RETRY_DELAYS", "30s,5m,1h"),
+
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
+
+		StorageDriver:     getEnvOrDefault("STORAGE_DRIVER", "s3"),
+		StorageLocalDir:   getEnvOrDefault("STORAGE_LOCAL_DIR", "data/objects"),
+		StoragePublicURL:  getEnvOrDefault("STORAGE_PUBLIC_URL", "http://localhost:8080/files"),
+		StorageSigningKey: os.Getenv("STORAGE_SIGNING_KEY"),
+
+		StorageCDNURL:          os.Getenv("STORAGE_CDN_URL"),
+		StorageSignedURLs:      getEnvAsBoolOrDefault("STORAGE_SIGNED_URLS", false),
+		StorageSignedURLExpiry: getEnvAsDurationOrDefault("STORAGE_SIGNED_URL_EXPIRY", time.Hour),
+
+		UploadMaxBytes:  getEnvAsIntOrDefault("UPLOAD_MAX_BYTES", 10<<20),
+		UploadURLExpiry: getEnvAsDurationOrDefault("UPLOAD_URL_EXPIRY", 15*time.Minute),
+
+		MetricsAddr: getEnvKeepEmptyOrDefault("METRICS_ADDR", ":9090"),
 	}
 
 	// Validate required AWS configuration
-	if config.AWSAccessKey == "" || config.AWSSecretKey == "" || config.AWSS3Bucket == "" || config.AWSRegion == "" {
+	if config.StorageDriver == "s3" && (config.AWSAccessKey == "" || config.AWSSecretKey == "" || config.AWSS3Bucket == "" || config.AWSRegion == "") {
 		log.Printf("Warning: Missing required AWS configuration")
 		log.Printf("AWS_ACCESS_KEY_ID set: %v", config.AWSAccessKey != "")
 		log.Printf("AWS_SECRET_ACCESS_KEY set: %v", config.AWSSecretKey != "")
@@ -107,6 +204,15 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return value
 }
 
+// getEnvKeepEmptyOrDefault is getEnvOrDefault for settings an empty value
+// turns off.
+func getEnvKeepEmptyOrDefault(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
 func getEnvAsIntOrDefault(key string, defaultValue int) int {
 	value := os.Getenv(key)
 	if value == "" {
@@ -118,3 +224,67 @@ func getEnvAsIntOrDefault(key string, defaultValue int) int {
 	}
 	return intValue
 }
+
+func getEnvAsDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return duration
+}
+
+func getEnvAsBoolOrDefault(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return boolValue
+}
+
+func getEnvAsRenditionsOrDefault(key, defaultValue string) []model.Rendition {
+	renditions, err := model.ParseRenditions(getEnvOrDefault(key, defaultValue))
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return renditions
+}
+
+// getEnvAsImageFormatsOrDefault parses a list of image formats. Unlike the
+// other settings, an empty value is kept, to produce no extra formats at all.
+func getEnvAsImageFormatsOrDefault(key, defaultValue string) []model.ImageFormat {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		value = defaultValue
+	}
+	formats, err := model.ParseImageFormats(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return formats
+}
+
+// getEnvAsDurationsOrDefault parses a comma-separated list of durations. An
+// empty value is kept, for an empty list.
+func getEnvAsDurationsOrDefault(key, defaultValue string) []time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		value = defaultValue
+	}
+	var durations []time.Duration
+	for _, part := range splitAndTrim(value, ",") {
+		duration, err := time.ParseDuration(part)
+		if err != nil || duration <= 0 {
+			log.Fatalf("Invalid %s: %q is not a positive duration", key, part)
+		}
+		durations = append(durations, duration)
+	}
+	return durations
+}
@@ -5,6 +5,8 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/iSparshP/product-management-system/internal/domain/model"
@@ -12,6 +14,15 @@ import (
 	"go.uber.org/zap"
 )
 
+// Headers of retried image processing tasks
+const (
+	// HeaderAttempt numbers the delivery of the task, from 1
+	HeaderAttempt = "attempt"
+	// HeaderNotBefore holds the RFC 3339 time before which the task must not
+	// be processed
+	HeaderNotBefore = "not-before"
+)
+
 type Consumer struct {
 	consumerGroup sarama.ConsumerGroup
 	topic         string
@@ -19,10 +30,20 @@ type Consumer struct {
 }
 
 func NewConsumer(brokers []string, groupID string, topic string, logger *zap.Logger) (*Consumer, error) {
+	return newConsumer(brokers, groupID, topic, sarama.OffsetNewest, logger)
+}
+
+// NewRetryConsumer consumes a retry topic. A new group starts from the oldest
+// message, as retries published before it first joined must not be lost.
+func NewRetryConsumer(brokers []string, groupID string, topic string, logger *zap.Logger) (*Consumer, error) {
+	return newConsumer(brokers, groupID, topic, sarama.OffsetOldest, logger)
+}
+
+func newConsumer(brokers []string, groupID string, topic string, initialOffset int64, logger *zap.Logger) (*Consumer, error) {
 	config := sarama.NewConfig()
 	config.Version = sarama.V2_8_0_0
 	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
-	config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	config.Consumer.Offsets.Initial = initialOffset
 
 	consumerGroup, err := sarama.NewConsumerGroup(brokers, groupID, config)
 	if err != nil {
@@ -77,12 +98,22 @@ func (h *consumerGroupHandler) Cleanup(session sarama.ConsumerGroupSession) erro
 	return nil
 }
 
+// ConsumeClaim processes the messages of one partition in order. Sarama runs
+// it in its own goroutine for each claimed partition, so partitions are
+// processed concurrently. The session context is cancelled on rebalance and
+// shutdown; a task interrupted that way is left unmarked, to be redelivered to
+// whichever member claims the partition next.
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for message := range claim.Messages() {
+	ctx := session.Context()
+	for {
 		select {
-		case <-session.Context().Done():
+		case <-ctx.Done():
 			return nil
-		default:
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+
 			var task model.ImageProcessingTask
 			if err := json.Unmarshal(message.Value, &task); err != nil {
 				h.logger.Error("Failed to unmarshal message",
@@ -95,18 +126,60 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 				continue
 			}
 
+			// Retried tasks wait until they are due. Every message of a retry
+			// topic has the same delay, so later messages are not due earlier.
+			task.Attempt = 1
+			if attempt, err := strconv.Atoi(header(message, HeaderAttempt)); err == nil && attempt > 1 {
+				task.Attempt = attempt
+			}
+			if notBefore, err := time.Parse(time.RFC3339Nano, header(message, HeaderNotBefore)); err == nil {
+				if !waitUntil(ctx, notBefore) {
+					return nil
+				}
+			}
+
 			// Process the image
-			if err := h.processor.ProcessImageTask(task); err != nil {
+			if err := h.processor.ProcessImageTask(ctx, task); err != nil {
+				if ctx.Err() != nil {
+					h.logger.Info("Image task interrupted, leaving it for redelivery",
+						zap.String("product_id", task.ProductID),
+						zap.Int32("partition", message.Partition),
+						zap.Int64("offset", message.Offset))
+					return nil
+				}
 				h.logger.Error("Failed to process image task",
 					zap.Error(err),
 					zap.String("product_id", task.ProductID),
 					zap.Strings("image_urls", task.ImageURLs))
-				// TODO: Implement retry logic or send to DLQ
-				// For now, we'll mark the message as processed to avoid infinite loops
+				// The processor scheduled a retry or sent the task to the DLQ already
 			}
 
 			session.MarkMessage(message, "")
 		}
 	}
-	return nil
+}
+
+func header(message *sarama.ConsumerMessage, key string) string {
+	for _, h := range message.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// waitUntil waits until t, returning false if ctx is cancelled first.
+func waitUntil(ctx context.Context, t time.Time) bool {
+	wait := time.Until(t)
+	if wait <= 0 {
+		return true
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
@@ -0,0 +1,170 @@
+// internal/infrastructure/kafka/dlq_reader.go
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/redis"
+)
+
+// Redis keys tracking the DLQ messages replayed, with their replay time, and
+// purged before DLQ entries were recorded in PostgreSQL. Messages are
+// identified as "partition-offset".
+const (
+	dlqReplayedKey = "dlq:replayed"
+	dlqPurgedKey   = "dlq:purged"
+)
+
+// DLQRecord is a message of the DLQ topic, with the state tracked for it in
+// Redis before DLQ entries were recorded in PostgreSQL.
+type DLQRecord struct {
+	Partition int32
+	Offset    int64
+	Message   model.DLQMessage
+	// Invalid is set for messages that do not decode as a DLQMessage
+	Invalid    bool
+	ReplayedAt *time.Time
+	Purged     bool
+}
+
+// DLQReader reads the DLQ topic, to import its messages as DLQ entries.
+type DLQReader struct {
+	client      sarama.Client
+	topic       string
+	redisClient *redis.Client
+}
+
+func NewDLQReader(brokers []string, topic string, redisClient *redis.Client) (*DLQReader, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_8_0_0
+	config.Consumer.Return.Errors = true
+
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DLQReader{
+		client:      client,
+		topic:       topic,
+		redisClient: redisClient,
+	}, nil
+}
+
+// Read calls fn for the messages currently in the topic, in order within
+// each partition, starting from the offset of the partition in from, or the
+// oldest one. An error of fn stops reading.
+func (r *DLQReader) Read(ctx context.Context, from map[int32]int64, fn func(DLQRecord) error) error {
+	partitions, err := r.client.Partitions(r.topic)
+	if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+		// Nothing was ever sent to the DLQ
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list DLQ partitions: %w", err)
+	}
+
+	purged, err := r.redisClient.SMembers(ctx, dlqPurgedKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load purged messages: %w", err)
+	}
+	replayed, err := r.redisClient.HGetAll(ctx, dlqReplayedKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load replayed messages: %w", err)
+	}
+	isPurged := make(map[string]bool, len(purged))
+	for _, id := range purged {
+		isPurged[id] = true
+	}
+
+	for _, partition := range partitions {
+		oldest, newest, err := r.offsets(partition)
+		if err != nil {
+			return err
+		}
+		// Messages removed by the retention are gone for good
+		start := max(from[partition], oldest)
+
+		err = r.readPartition(ctx, partition, start, newest, func(message *sarama.ConsumerMessage) error {
+			id := fmt.Sprintf("%d-%d", message.Partition, message.Offset)
+			record := DLQRecord{
+				Partition: message.Partition,
+				Offset:    message.Offset,
+				Purged:    isPurged[id],
+			}
+			if err := json.Unmarshal(message.Value, &record.Message); err != nil {
+				record.Invalid = true
+			}
+			if t, err := time.Parse(time.RFC3339Nano, replayed[id]); err == nil {
+				record.ReplayedAt = &t
+			}
+			return fn(record)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *DLQReader) Close() error {
+	return r.client.Close()
+}
+
+func (r *DLQReader) offsets(partition int32) (oldest, newest int64, err error) {
+	oldest, err = r.client.GetOffset(r.topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get DLQ offsets: %w", err)
+	}
+	newest, err = r.client.GetOffset(r.topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get DLQ offsets: %w", err)
+	}
+	return oldest, newest, nil
+}
+
+// readPartition calls fn for the messages of a partition from offset from
+// up to, but excluding, offset to.
+func (r *DLQReader) readPartition(ctx context.Context, partition int32, from, to int64, fn func(*sarama.ConsumerMessage) error) error {
+	if from >= to {
+		return nil
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(r.client)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	partitionConsumer, err := consumer.ConsumePartition(r.topic, partition, from)
+	if err != nil {
+		return fmt.Errorf("failed to read DLQ partition %d: %w", partition, err)
+	}
+	defer partitionConsumer.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-partitionConsumer.Errors():
+			return fmt.Errorf("failed to read DLQ partition %d: %w", partition, err)
+		case message := <-partitionConsumer.Messages():
+			if message.Offset >= to {
+				return nil
+			}
+			if err := fn(message); err != nil {
+				return err
+			}
+			if message.Offset == to-1 {
+				return nil
+			}
+		}
+	}
+}
@@ -48,3 +48,54 @@ func (p *Publisher) Publish(ctx context.Context, message []byte) error {
 
 	return nil
 }
+
+// PublishWithKey publishes a keyed message; messages sharing a key land on the
+// same partition and are consumed in order.
+func (p *Publisher) PublishWithKey(ctx context.Context, key string, message []byte) error {
+	msg := &sarama.ProducerMessage{
+		Topic: p.topic,
+		Key:   sarama.StringEncoder(key),
+		Value: sarama.ByteEncoder(message),
+	}
+
+	_, _, err := p.producer.SendMessage(msg)
+	if err != nil {
+		p.logger.Error("Failed to send message to Kafka", zap.Error(err), zap.String("key", key))
+		return err
+	}
+
+	return nil
+}
+
+// PublishWithHeaders publishes a keyed message carrying the given headers.
+func (p *Publisher) PublishWithHeaders(ctx context.Context, key string, message []byte, headers map[string]string) error {
+	msg := &sarama.ProducerMessage{
+		Topic: p.topic,
+		Key:   sarama.StringEncoder(key),
+		Value: sarama.ByteEncoder(message),
+	}
+	for name, value := range headers {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(name), Value: []byte(value)})
+	}
+
+	_, _, err := p.producer.SendMessage(msg)
+	if err != nil {
+		p.logger.Error("Failed to send message to Kafka", zap.Error(err), zap.String("key", key), zap.String("topic", p.topic))
+		return err
+	}
+
+	return nil
+}
+
+// WithTopic returns a publisher to another topic sharing the same producer.
+func (p *Publisher) WithTopic(topic string) *Publisher {
+	return &Publisher{
+		producer: p.producer,
+		topic:    topic,
+		logger:   p.logger,
+	}
+}
+
+func (p *Publisher) Topic() string {
+	return p.topic
+}
@@ -0,0 +1,244 @@
+// internal/infrastructure/objectstore/local.go
+
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+)
+
+// tempPrefix marks files being written and checksumPrefix the files holding
+// the checksum of an object; List skips both
+const (
+	tempPrefix     = ".upload-"
+	checksumPrefix = ".sha256-"
+)
+
+// LocalStore keeps objects as files under a root directory, one file per key.
+// The API serves them under baseURL. Content types are derived from the key
+// extension.
+type LocalStore struct {
+	urlSigner
+	root    string
+	baseURL string
+}
+
+func NewLocalStore(root, baseURL string, signingKey []byte) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStore{
+		urlSigner: urlSigner{key: signingKey},
+		root:      root,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Put writes to a temporary file renamed into place, so readers never see a
+// partial object. The checksum is kept in a file beside the object, written
+// first, so that an object is never visible without its checksum.
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	if err := storage.ValidateKey(key); err != nil {
+		return err
+	}
+	filePath := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(filePath), tempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	checksum := storage.NewChecksum(contextReader{ctx: ctx, r: body})
+	if _, err := io.Copy(tempFile, checksum); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := writeFileAtomic(checksumPath(filePath), []byte(checksum.Sum())); err != nil {
+		return fmt.Errorf("failed to write checksum: %w", err)
+	}
+	if err := os.Rename(tempFile.Name(), filePath); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes a small file through a temporary file renamed into
+// place.
+func writeFileAtomic(filePath string, data []byte) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(filePath), tempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), filePath)
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return nil, nil, storage.ErrObjectNotFound
+	}
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, storage.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	if stat.IsDir() {
+		f.Close()
+		return nil, nil, storage.ErrObjectNotFound
+	}
+	// Objects written before checksums were kept have none
+	checksum, _ := os.ReadFile(checksumPath(s.path(key)))
+	return f, &storage.ObjectInfo{
+		Key:          key,
+		Size:         stat.Size(),
+		ContentType:  storage.ContentType(key),
+		LastModified: stat.ModTime(),
+		Checksum:     string(checksum),
+	}, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if err := storage.ValidateKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	if err := os.Remove(checksumPath(s.path(key))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete checksum: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) Exists(ctx context.Context, key string) (bool, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return false, nil
+	}
+	stat, err := os.Stat(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check file: %w", err)
+	}
+	return !stat.IsDir(), nil
+}
+
+func (s *LocalStore) List(ctx context.Context, prefix string, fn func(storage.ObjectInfo) error) error {
+	// Only walk the directory holding the prefix
+	dir := s.root
+	if i := strings.LastIndex(prefix, "/"); i > 0 && storage.ValidateKey(path.Clean(prefix[:i])) == nil {
+		dir = s.path(path.Clean(prefix[:i]))
+	}
+
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) || strings.HasPrefix(entry.Name(), checksumPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		stat, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return fn(storage.ObjectInfo{
+			Key:          key,
+			Size:         stat.Size(),
+			ContentType:  storage.ContentType(key),
+			LastModified: stat.ModTime(),
+		})
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *LocalStore) PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return s.presign(s.URL(key), http.MethodGet, key, 0, expiry)
+}
+
+func (s *LocalStore) PresignedPutURL(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (string, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return "", err
+	}
+	return s.presign(s.URL(key), http.MethodPut, key, size, expiry)
+}
+
+func (s *LocalStore) KeyFromURL(url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, s.baseURL+"/")
+	key, _, _ = strings.Cut(key, "?")
+	return key, ok && key != ""
+}
+
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+// checksumPath returns the path of the file holding the checksum of an object.
+func checksumPath(filePath string) string {
+	return filepath.Join(filepath.Dir(filePath), checksumPrefix+filepath.Base(filePath))
+}
+
+// contextReader stops reading once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
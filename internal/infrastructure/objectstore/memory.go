@@ -0,0 +1,136 @@
+// internal/infrastructure/objectstore/memory.go
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+)
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+	checksum    string
+	modTime     time.Time
+}
+
+// MemoryStore keeps objects in memory, for tests. Objects are lost when the
+// process exits, and other processes do not see them.
+type MemoryStore struct {
+	urlSigner
+	baseURL string
+
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+func NewMemoryStore(baseURL string, signingKey []byte) *MemoryStore {
+	return &MemoryStore{
+		urlSigner: urlSigner{key: signingKey},
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		objects:   make(map[string]memoryObject),
+	}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	if err := storage.ValidateKey(key); err != nil {
+		return err
+	}
+	checksum := storage.NewChecksum(contextReader{ctx: ctx, r: body})
+	data, err := io.ReadAll(checksum)
+	if err != nil {
+		return fmt.Errorf("failed to read object: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = memoryObject{data: data, contentType: contentType, checksum: checksum.Sum(), modTime: time.Now()}
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	s.mu.RLock()
+	object, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil, storage.ErrObjectNotFound
+	}
+	info := s.info(key, object)
+	info.Checksum = object.checksum
+	return io.NopCloser(bytes.NewReader(object.data)), &info, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *MemoryStore) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+// List calls fn in key order, without holding the lock.
+func (s *MemoryStore) List(ctx context.Context, prefix string, fn func(storage.ObjectInfo) error) error {
+	s.mu.RLock()
+	var infos []storage.ObjectInfo
+	for key, object := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, s.info(key, object))
+		}
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(infos, func(a, b storage.ObjectInfo) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *MemoryStore) PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return s.presign(s.URL(key), http.MethodGet, key, 0, expiry)
+}
+
+func (s *MemoryStore) PresignedPutURL(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (string, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return "", err
+	}
+	return s.presign(s.URL(key), http.MethodPut, key, size, expiry)
+}
+
+func (s *MemoryStore) KeyFromURL(url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, s.baseURL+"/")
+	key, _, _ = strings.Cut(key, "?")
+	return key, ok && key != ""
+}
+
+func (s *MemoryStore) info(key string, object memoryObject) storage.ObjectInfo {
+	return storage.ObjectInfo{
+		Key:          key,
+		Size:         int64(len(object.data)),
+		ContentType:  object.contentType,
+		LastModified: object.modTime,
+	}
+}
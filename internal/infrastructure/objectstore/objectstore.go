@@ -0,0 +1,42 @@
+// internal/infrastructure/objectstore/objectstore.go
+
+// Package objectstore provides the object stores besides S3 and selects the
+// one configured.
+package objectstore
+
+import (
+	"fmt"
+
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/config"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/s3"
+	"go.uber.org/zap"
+)
+
+// Storage drivers. The memory store is for tests only: the API and the image
+// processor run as separate processes, which would not share its objects.
+const (
+	DriverS3     = "s3"
+	DriverLocal  = "local"
+	DriverMemory = "memory"
+)
+
+// New creates the object store of the configured driver.
+func New(cfg *config.Config, logger *zap.Logger) (storage.ObjectStore, error) {
+	switch cfg.StorageDriver {
+	case DriverS3:
+		return s3.NewS3Client(cfg.AWSAccessKey, cfg.AWSSecretKey, cfg.AWSRegion, cfg.AWSS3Bucket, cfg.AWSEndpoint, cfg.AWSS3PathStyle, logger), nil
+	case DriverLocal:
+		return NewLocalStore(cfg.StorageLocalDir, cfg.StoragePublicURL, []byte(cfg.StorageSigningKey))
+	case DriverMemory:
+		return nil, fmt.Errorf("storage driver %q is for tests only: the API and the image processor would not share its objects", cfg.StorageDriver)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
+
+// ServedByAPI reports whether the objects of a driver are served by the API
+// rather than by the store itself.
+func ServedByAPI(driver string) bool {
+	return driver == DriverLocal
+}
@@ -0,0 +1,354 @@
+// internal/infrastructure/objectstore/objectstore_test.go
+
+package objectstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+const testBaseURL = "http://localhost:8080/files"
+
+var testSigningKey = []byte("test-signing-key")
+
+// signatureVerifier is how the API checks the URLs the stores presign.
+type signatureVerifier interface {
+	VerifySignature(method, key, expires, size, signature string) error
+}
+
+// testStores returns a fresh store of each driver served by the API.
+func testStores(t *testing.T) map[string]storage.ObjectStore {
+	local, err := NewLocalStore(t.TempDir(), testBaseURL, testSigningKey)
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	return map[string]storage.ObjectStore{
+		"memory": NewMemoryStore(testBaseURL, testSigningKey),
+		"local":  local,
+	}
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestStorePutGet(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			key := "products/p1/abc-card.jpg"
+			if err := store.Put(ctx, key, strings.NewReader("first"), "image/jpeg"); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			// Overwriting replaces the content and the checksum
+			if err := store.Put(ctx, key, strings.NewReader("second"), "image/jpeg"); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+
+			body, info, err := store.Get(ctx, key)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			data, err := io.ReadAll(body)
+			body.Close()
+			if err != nil {
+				t.Fatalf("reading body: %v", err)
+			}
+			if string(data) != "second" {
+				t.Errorf("Get() content = %q, want %q", data, "second")
+			}
+			if info.Key != key || info.Size != int64(len("second")) || info.ContentType != "image/jpeg" {
+				t.Errorf("Get() info = %+v", info)
+			}
+			if info.Checksum != sha256Hex("second") {
+				t.Errorf("Get() checksum = %q, want %q", info.Checksum, sha256Hex("second"))
+			}
+			if info.LastModified.IsZero() {
+				t.Error("Get() LastModified is zero")
+			}
+		})
+	}
+}
+
+func TestStoreMissingObject(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := store.Get(ctx, "products/missing.jpg"); !errors.Is(err, storage.ErrObjectNotFound) {
+				t.Errorf("Get() error = %v, want ErrObjectNotFound", err)
+			}
+			exists, err := store.Exists(ctx, "products/missing.jpg")
+			if err != nil || exists {
+				t.Errorf("Exists() = %v, %v, want false", exists, err)
+			}
+			if err := store.Delete(ctx, "products/missing.jpg"); err != nil {
+				t.Errorf("Delete() of a missing object error = %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			key := "uploads/u1.png"
+			if err := store.Put(ctx, key, strings.NewReader("png"), "image/png"); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			if exists, err := store.Exists(ctx, key); err != nil || !exists {
+				t.Fatalf("Exists() = %v, %v, want true", exists, err)
+			}
+			if err := store.Delete(ctx, key); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if exists, err := store.Exists(ctx, key); err != nil || exists {
+				t.Errorf("Exists() after Delete() = %v, %v, want false", exists, err)
+			}
+		})
+	}
+}
+
+func TestStoreRejectsInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"", "../escape.jpg", "/abs.jpg", "a/../../b.jpg"} {
+				if err := store.Put(ctx, key, strings.NewReader("x"), "image/jpeg"); !errors.Is(err, storage.ErrInvalidKey) {
+					t.Errorf("Put(%q) error = %v, want ErrInvalidKey", key, err)
+				}
+				if _, err := store.PresignedPutURL(ctx, key, "image/jpeg", 1, time.Minute); !errors.Is(err, storage.ErrInvalidKey) {
+					t.Errorf("PresignedPutURL(%q) error = %v, want ErrInvalidKey", key, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{
+		"products/p2/b-card.jpg",
+		"products/p1/a-card.jpg",
+		"products/p1/a-zoom.jpg",
+		"uploads/u1.jpg",
+	}
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range keys {
+				if err := store.Put(ctx, key, strings.NewReader(key), "image/jpeg"); err != nil {
+					t.Fatalf("Put(%q) error = %v", key, err)
+				}
+			}
+
+			tests := []struct {
+				prefix string
+				want   []string
+			}{
+				{"products/", []string{"products/p1/a-card.jpg", "products/p1/a-zoom.jpg", "products/p2/b-card.jpg"}},
+				{"products/p1/a-c", []string{"products/p1/a-card.jpg"}},
+				{"uploads/", []string{"uploads/u1.jpg"}},
+				{"missing/", nil},
+			}
+			for _, tt := range tests {
+				var got []string
+				err := store.List(ctx, tt.prefix, func(info storage.ObjectInfo) error {
+					if info.Size != int64(len(info.Key)) {
+						t.Errorf("List(%q) size of %s = %d", tt.prefix, info.Key, info.Size)
+					}
+					got = append(got, info.Key)
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("List(%q) error = %v", tt.prefix, err)
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("List(%q) = %v, want %v", tt.prefix, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStorePresignedURLs(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			verifier := store.(signatureVerifier)
+			key := "uploads/u1.jpg"
+
+			signed, err := store.PresignedURL(ctx, key, time.Minute)
+			if err != nil {
+				t.Fatalf("PresignedURL() error = %v", err)
+			}
+			if got, ok := store.KeyFromURL(signed); !ok || got != key {
+				t.Errorf("KeyFromURL(%q) = %q, %v, want %q", signed, got, ok, key)
+			}
+			query := mustQuery(t, signed)
+			if err := verifier.VerifySignature(http.MethodGet, key, query.Get("expires"), "", query.Get("signature")); err != nil {
+				t.Errorf("VerifySignature() of a presigned GET error = %v", err)
+			}
+			if err := verifier.VerifySignature(http.MethodGet, "uploads/other.jpg", query.Get("expires"), "", query.Get("signature")); err == nil {
+				t.Error("VerifySignature() accepted the signature of another key")
+			}
+			if err := verifier.VerifySignature(http.MethodPut, key, query.Get("expires"), "", query.Get("signature")); err == nil {
+				t.Error("VerifySignature() accepted a GET signature for a PUT")
+			}
+
+			putURL, err := store.PresignedPutURL(ctx, key, "image/jpeg", 42, time.Minute)
+			if err != nil {
+				t.Fatalf("PresignedPutURL() error = %v", err)
+			}
+			query = mustQuery(t, putURL)
+			if err := verifier.VerifySignature(http.MethodPut, key, query.Get("expires"), "42", query.Get("signature")); err != nil {
+				t.Errorf("VerifySignature() of a presigned PUT error = %v", err)
+			}
+			if err := verifier.VerifySignature(http.MethodPut, key, query.Get("expires"), "43", query.Get("signature")); err == nil {
+				t.Error("VerifySignature() accepted another size")
+			}
+
+			expired, err := store.PresignedURL(ctx, key, -time.Minute)
+			if err != nil {
+				t.Fatalf("PresignedURL() error = %v", err)
+			}
+			query = mustQuery(t, expired)
+			if err := verifier.VerifySignature(http.MethodGet, key, query.Get("expires"), "", query.Get("signature")); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifySignature() of an expired URL error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestPresignedURLsNeedSigningKey(t *testing.T) {
+	store := NewMemoryStore(testBaseURL, nil)
+	if _, err := store.PresignedURL(context.Background(), "uploads/u1.jpg", time.Minute); !errors.Is(err, ErrSigningDisabled) {
+		t.Errorf("PresignedURL() error = %v, want ErrSigningDisabled", err)
+	}
+	if err := store.VerifySignature(http.MethodGet, "uploads/u1.jpg", "9999999999", "", "forged"); !errors.Is(err, ErrSigningDisabled) {
+		t.Errorf("VerifySignature() error = %v, want ErrSigningDisabled", err)
+	}
+}
+
+func TestLocalStoreKeepsFilesUnderRoot(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalStore(root, testBaseURL, testSigningKey)
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := store.Put(ctx, "products/p1/a-card.jpg", strings.NewReader("jpeg"), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, "products", "p1"))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	// No temporary file is left behind
+	if strings.Join(names, ",") != checksumPrefix+"a-card.jpg,a-card.jpg" {
+		t.Errorf("files = %v", names)
+	}
+	if _, _, err := store.Get(ctx, "../"+filepath.Base(root)+"/products/p1/a-card.jpg"); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Errorf("Get() outside the root error = %v, want ErrObjectNotFound", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	logger := zap.NewNop()
+	tests := []struct {
+		driver  string
+		wantErr bool
+	}{
+		{DriverLocal, false},
+		{DriverMemory, true},
+		{"ftp", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			cfg := &config.Config{StorageDriver: tt.driver, StorageLocalDir: t.TempDir(), StoragePublicURL: testBaseURL}
+			store, err := New(cfg, logger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && store == nil {
+				t.Error("New() returned no store")
+			}
+		})
+	}
+
+	if !ServedByAPI(DriverLocal) || ServedByAPI(DriverMemory) || ServedByAPI(DriverS3) {
+		t.Error("ServedByAPI() should only hold for the local driver")
+	}
+}
+
+func mustQuery(t *testing.T, rawURL string) url.Values {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) error = %v", rawURL, err)
+	}
+	if !strings.HasPrefix(rawURL, testBaseURL+"/") {
+		t.Errorf("URL %q is not under %s", rawURL, testBaseURL)
+	}
+	return u.Query()
+}
+
+func TestURLBuilder(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(testBaseURL, testSigningKey)
+	upload := "uploads/4f1c2a9e-6b1d-4c55-9a57-0f6c1d2e3b4a.jpg"
+
+	tests := []struct {
+		name       string
+		cfg        config.Config
+		ref        string
+		want       string
+		wantSigned bool
+	}{
+		{"key", config.Config{}, "products/p1/a-card.jpg", testBaseURL + "/products/p1/a-card.jpg", false},
+		{"URL of the store", config.Config{}, testBaseURL + "/products/p1/a-card.jpg", testBaseURL + "/products/p1/a-card.jpg", false},
+		{"external URL", config.Config{}, "https://example.com/a.jpg", "https://example.com/a.jpg", false},
+		{"CDN", config.Config{StorageCDNURL: "https://cdn.example.com"}, "products/p1/a-card.jpg", "https://cdn.example.com/products/p1/a-card.jpg", false},
+		{"signed", config.Config{StorageSignedURLs: true, StorageSignedURLExpiry: time.Hour}, "products/p1/a-card.jpg", testBaseURL + "/products/p1/a-card.jpg", true},
+		{"upload", config.Config{UploadURLExpiry: time.Minute}, upload, testBaseURL + "/" + upload, true},
+		{"upload bypasses the CDN", config.Config{StorageCDNURL: "https://cdn.example.com", UploadURLExpiry: time.Minute}, upload, testBaseURL + "/" + upload, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder, err := NewURLBuilder(store, &tt.cfg)
+			if err != nil {
+				t.Fatalf("NewURLBuilder() error = %v", err)
+			}
+			got, err := builder.ObjectURL(ctx, tt.ref)
+			if err != nil {
+				t.Fatalf("ObjectURL() error = %v", err)
+			}
+			base, query, _ := strings.Cut(got, "?")
+			if base != tt.want {
+				t.Errorf("ObjectURL() = %q, want %q", got, tt.want)
+			}
+			if signed := strings.Contains(query, "signature="); signed != tt.wantSigned {
+				t.Errorf("ObjectURL() = %q, signed %v, want %v", got, signed, tt.wantSigned)
+			}
+		})
+	}
+}
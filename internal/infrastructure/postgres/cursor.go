@@ -0,0 +1,99 @@
+// internal/infrastructure/postgres/cursor.go
+
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+)
+
+// productCursor is the position after the last row of a page: the value of
+// the sort column and the id used as tie-breaker.
+type productCursor struct {
+	Sort  model.SortField `json:"s"`
+	Order model.SortOrder `json:"o"`
+	Value json.RawMessage `json:"v"`
+	ID    uuid.UUID       `json:"id"`
+}
+
+// sortColumns maps the public sort fields to product columns.
+var sortColumns = map[model.SortField]string{
+	model.SortByCreatedAt: "created_at",
+	model.SortByPrice:     "product_price",
+	model.SortByName:      "product_name",
+}
+
+func encodeCursor(page model.PageRequest, product *model.Product) (string, error) {
+	var value interface{}
+	switch page.Sort {
+	case model.SortByRelevance:
+		value = product.SearchRank
+	case model.SortByPrice:
+		value = product.ProductPrice
+	case model.SortByName:
+		value = product.ProductName
+	default:
+		value = product.CreatedAt
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(productCursor{
+		Sort:  page.Sort,
+		Order: page.Order,
+		Value: raw,
+		ID:    product.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the sort value and id stored in the cursor. The cursor
+// must have been issued for the same sort field and order as the page.
+func decodeCursor(page model.PageRequest) (interface{}, uuid.UUID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("%w: %v", repository.ErrInvalidCursor, err)
+	}
+
+	var cursor productCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, uuid.Nil, fmt.Errorf("%w: %v", repository.ErrInvalidCursor, err)
+	}
+	if cursor.Sort != page.Sort || cursor.Order != page.Order {
+		return nil, uuid.Nil, fmt.Errorf("%w: cursor was issued for a different sort", repository.ErrInvalidCursor)
+	}
+
+	var value interface{}
+	switch page.Sort {
+	case model.SortByRelevance, model.SortByPrice:
+		var number float64
+		err = json.Unmarshal(cursor.Value, &number)
+		value = number
+	case model.SortByName:
+		var name string
+		err = json.Unmarshal(cursor.Value, &name)
+		value = name
+	default:
+		var createdAt time.Time
+		err = json.Unmarshal(cursor.Value, &createdAt)
+		value = createdAt
+	}
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("%w: %v", repository.ErrInvalidCursor, err)
+	}
+
+	return value, cursor.ID, nil
+}
@@ -0,0 +1,171 @@
+// internal/infrastructure/postgres/dlq_repository.go
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const saveDLQBackfillOffsetQuery = `INSERT INTO dlq_backfill_offsets (topic_partition, next_offset)
+VALUES (?, ?)
+ON CONFLICT (topic_partition) DO UPDATE SET
+	next_offset = EXCLUDED.next_offset,
+	updated_at = now()`
+
+type DLQRepo struct {
+	DB *gorm.DB
+}
+
+func NewDLQRepo(db *gorm.DB) repository.DLQRepository {
+	return &DLQRepo{
+		DB: db,
+	}
+}
+
+func (r *DLQRepo) Create(ctx context.Context, entry *model.DLQEntry) error {
+	return r.DB.WithContext(ctx).Create(entry).Error
+}
+
+func (r *DLQRepo) List(ctx context.Context, filter model.DLQFilter) ([]model.DLQEntry, error) {
+	query := applyDLQFilter(r.DB.WithContext(ctx).Model(&model.DLQEntry{}), filter).
+		Order("failed_at DESC, id")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var entries []model.DLQEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *DLQRepo) Summarize(ctx context.Context, filter model.DLQFilter) ([]model.DLQSummary, error) {
+	var summary []model.DLQSummary
+	err := applyDLQFilter(r.DB.WithContext(ctx).Model(&model.DLQEntry{}), filter).
+		Select("error_class, status, count(*) AS count, count(DISTINCT product_id) AS products, max(failed_at) AS last_failed").
+		Group("error_class, status").
+		Order("count DESC, error_class, status").
+		Scan(&summary).Error
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func (r *DLQRepo) Get(ctx context.Context, id string) (*model.DLQEntry, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, repository.ErrDLQEntryNotFound
+	}
+
+	var entry model.DLQEntry
+	if err := r.DB.WithContext(ctx).First(&entry, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrDLQEntryNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *DLQRepo) MarkReplayed(ctx context.Context, id string, at time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&model.DLQEntry{}).
+		Where("id = ? AND status = ?", id, model.DLQStatusOpen).
+		Updates(map[string]interface{}{
+			"status":      model.DLQStatusReplayed,
+			"resolved_at": at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *DLQRepo) UnmarkReplayed(ctx context.Context, id string) error {
+	return r.DB.WithContext(ctx).Model(&model.DLQEntry{}).
+		Where("id = ? AND status = ?", id, model.DLQStatusReplayed).
+		Updates(map[string]interface{}{
+			"status":      model.DLQStatusOpen,
+			"resolved_at": nil,
+		}).Error
+}
+
+func (r *DLQRepo) Resolve(ctx context.Context, ids []string, status model.DLQStatus, resolution string, at time.Time) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// Reopened entries are no longer resolved
+	var resolvedAt *time.Time
+	if status != model.DLQStatusOpen {
+		resolvedAt = &at
+	}
+	result := r.DB.WithContext(ctx).Model(&model.DLQEntry{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"resolution":  resolution,
+			"resolved_at": resolvedAt,
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *DLQRepo) Purge(ctx context.Context, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := r.DB.WithContext(ctx).Where("id IN ?", ids).Delete(&model.DLQEntry{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *DLQRepo) Import(ctx context.Context, entry *model.DLQEntry) (bool, error) {
+	result := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *DLQRepo) BackfillOffsets(ctx context.Context) (map[int32]int64, error) {
+	var rows []struct {
+		TopicPartition int32
+		NextOffset     int64
+	}
+	if err := r.DB.WithContext(ctx).Raw("SELECT topic_partition, next_offset FROM dlq_backfill_offsets").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[int32]int64, len(rows))
+	for _, row := range rows {
+		offsets[row.TopicPartition] = row.NextOffset
+	}
+	return offsets, nil
+}
+
+func (r *DLQRepo) SaveBackfillOffset(ctx context.Context, partition int32, next int64) error {
+	return r.DB.WithContext(ctx).Exec(saveDLQBackfillOffsetQuery, partition, next).Error
+}
+
+func applyDLQFilter(query *gorm.DB, filter model.DLQFilter) *gorm.DB {
+	if filter.ProductID != "" {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.ErrorClass != "" {
+		query = query.Where("error_class = ?", filter.ErrorClass)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Error != "" {
+		query = query.Where("error ILIKE ?", "%"+filter.Error+"%")
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("failed_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("failed_at < ?", filter.Until)
+	}
+	return query
+}
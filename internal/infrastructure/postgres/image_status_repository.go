@@ -0,0 +1,90 @@
+// internal/infrastructure/postgres/image_status_repository.go
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+const initImageStatusQuery = `INSERT INTO product_image_statuses (product_id, position, source_url)
+VALUES (?, ?, ?)
+ON CONFLICT (product_id, position) DO UPDATE SET
+	source_url = EXCLUDED.source_url,
+	status = 'pending',
+	error = '',
+	error_class = '',
+	attempts = 0,
+	digest = '',
+	renditions = '{}',
+	variants = '[]',
+	original_width = 0,
+	original_height = 0,
+	original_format = '',
+	metadata = '{}',
+	updated_at = now()
+WHERE product_image_statuses.source_url <> EXCLUDED.source_url`
+
+type ImageStatusRepo struct {
+	DB *gorm.DB
+}
+
+func NewImageStatusRepo(db *gorm.DB) repository.ImageStatusRepository {
+	return &ImageStatusRepo{
+		DB: db,
+	}
+}
+
+func (r *ImageStatusRepo) Init(ctx context.Context, productID string, sourceURLs []string) ([]model.ProductImageStatus, error) {
+	var statuses []model.ProductImageStatus
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for position, sourceURL := range sourceURLs {
+			if err := tx.Exec(initImageStatusQuery, productID, position, sourceURL).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("product_id = ? AND position >= ?", productID, len(sourceURLs)).
+			Delete(&model.ProductImageStatus{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("product_id = ?", productID).Order("position").Find(&statuses).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+func (r *ImageStatusRepo) Save(ctx context.Context, status *model.ProductImageStatus) error {
+	status.UpdatedAt = time.Now()
+	return r.DB.WithContext(ctx).Model(&model.ProductImageStatus{}).
+		Where("product_id = ? AND position = ? AND source_url = ?", status.ProductID, status.Position, status.SourceURL).
+		Updates(map[string]interface{}{
+			"status":          status.Status,
+			"error":           status.Error,
+			"error_class":     status.ErrorClass,
+			"attempts":        status.Attempts,
+			"digest":          status.Digest,
+			"renditions":      status.Renditions,
+			"variants":        status.Variants,
+			"original_width":  status.OriginalWidth,
+			"original_height": status.OriginalHeight,
+			"original_format": status.OriginalFormat,
+			"metadata":        status.Metadata,
+			"updated_at":      status.UpdatedAt,
+		}).Error
+}
+
+func (r *ImageStatusRepo) GetByProductID(ctx context.Context, productID string) ([]model.ProductImageStatus, error) {
+	var statuses []model.ProductImageStatus
+	if err := r.DB.WithContext(ctx).Where("product_id = ?", productID).Order("position").Find(&statuses).Error; err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
@@ -0,0 +1,31 @@
+-- Baseline schema. Statements are idempotent so databases previously created
+-- by GORM AutoMigrate can adopt the migration history.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS users (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name varchar(255) NOT NULL,
+    email varchar(255) NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS products (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id uuid NOT NULL,
+    product_name varchar(255) NOT NULL,
+    product_description text,
+    product_images jsonb NOT NULL,
+    compressed_product_images jsonb,
+    product_price decimal(10,2) NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
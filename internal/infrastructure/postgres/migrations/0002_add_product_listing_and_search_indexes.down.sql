@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_products_user_name;
+DROP INDEX IF EXISTS idx_products_user_price;
+DROP INDEX IF EXISTS idx_products_user_created_at;
+DROP INDEX IF EXISTS idx_products_name_trgm;
+DROP INDEX IF EXISTS idx_products_search_vector;
+
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
+
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,15 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Full-text search over name (weight A) and description (weight B)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(product_name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(product_description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (product_name gin_trgm_ops);
+
+-- Keyset pagination of the product listing
+CREATE INDEX IF NOT EXISTS idx_products_user_created_at ON products (user_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_products_user_price ON products (user_id, product_price, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_products_user_name ON products (user_id, product_name, id) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- Optimistic concurrency: bumped on every write and exposed as the ETag
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,18 @@
+-- Transactional outbox: messages are written in the same transaction as the
+-- product change and published to Kafka by the outbox relay.
+CREATE TABLE IF NOT EXISTS outbox (
+    id bigserial PRIMARY KEY,
+    aggregate_id uuid NOT NULL,
+    topic varchar(255) NOT NULL,
+    payload jsonb NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text NOT NULL DEFAULT '',
+    next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    locked_until timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    delivered_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (topic, id) WHERE delivered_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate_pending ON outbox (aggregate_id, id) WHERE delivered_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_delivered_at ON outbox (delivered_at) WHERE delivered_at IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE products DROP COLUMN IF EXISTS image_processing_status;
+
+DROP TABLE IF EXISTS product_image_statuses;
@@ -0,0 +1,19 @@
+-- Processing state of each source image, by its position in product_images
+CREATE TABLE IF NOT EXISTS product_image_statuses (
+    product_id uuid NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    source_url text NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    error text NOT NULL DEFAULT '',
+    attempts integer NOT NULL DEFAULT 0,
+    compressed_url text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (product_id, position)
+);
+
+-- Aggregate over all images of the product
+ALTER TABLE products ADD COLUMN IF NOT EXISTS image_processing_status varchar(20) NOT NULL DEFAULT 'pending';
+
+UPDATE products SET image_processing_status = 'completed'
+WHERE jsonb_array_length(coalesce(compressed_product_images, '[]'::jsonb)) > 0;
@@ -0,0 +1,13 @@
+ALTER TABLE product_image_statuses ADD COLUMN IF NOT EXISTS compressed_url text NOT NULL DEFAULT '';
+
+UPDATE product_image_statuses SET compressed_url = renditions ->> 'detail'
+WHERE renditions ->> 'detail' IS NOT NULL;
+
+ALTER TABLE product_image_statuses DROP COLUMN IF EXISTS renditions;
+
+UPDATE products SET compressed_product_images = (
+    SELECT coalesce(jsonb_agg(image -> 'detail' ORDER BY position), '[]'::jsonb)
+    FROM jsonb_array_elements(compressed_product_images) WITH ORDINALITY AS images (image, position)
+    WHERE image ->> 'detail' IS NOT NULL
+)
+WHERE jsonb_typeof(compressed_product_images) = 'array';
@@ -0,0 +1,16 @@
+-- Compressed images become one object of rendition name to URL per source
+-- image. The single image produced before renditions existed is the "detail" one.
+UPDATE products SET compressed_product_images = (
+    SELECT coalesce(jsonb_agg(
+        CASE WHEN jsonb_typeof(image) = 'string' THEN jsonb_build_object('detail', image) ELSE image END
+        ORDER BY position), '[]'::jsonb)
+    FROM jsonb_array_elements(compressed_product_images) WITH ORDINALITY AS images (image, position)
+)
+WHERE jsonb_typeof(compressed_product_images) = 'array';
+
+ALTER TABLE product_image_statuses ADD COLUMN IF NOT EXISTS renditions jsonb NOT NULL DEFAULT '{}';
+
+UPDATE product_image_statuses SET renditions = jsonb_build_object('detail', compressed_url)
+WHERE compressed_url <> '';
+
+ALTER TABLE product_image_statuses DROP COLUMN IF EXISTS compressed_url;
@@ -0,0 +1,3 @@
+ALTER TABLE product_image_statuses DROP COLUMN IF EXISTS variants;
+
+ALTER TABLE products DROP COLUMN IF EXISTS image_variants;
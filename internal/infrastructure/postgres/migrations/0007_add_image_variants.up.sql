@@ -0,0 +1,4 @@
+-- Every encoding of every rendition, per source image; srcsets are derived from it
+ALTER TABLE products ADD COLUMN IF NOT EXISTS image_variants jsonb;
+
+ALTER TABLE product_image_statuses ADD COLUMN IF NOT EXISTS variants jsonb NOT NULL DEFAULT '[]';
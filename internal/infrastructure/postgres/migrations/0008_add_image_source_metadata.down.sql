@@ -0,0 +1,5 @@
+ALTER TABLE product_image_statuses
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS original_format,
+    DROP COLUMN IF EXISTS original_height,
+    DROP COLUMN IF EXISTS original_width;
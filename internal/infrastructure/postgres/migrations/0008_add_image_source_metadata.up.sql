@@ -0,0 +1,6 @@
+-- Source image details recorded by the image processor; renditions carry no metadata
+ALTER TABLE product_image_statuses
+    ADD COLUMN IF NOT EXISTS original_width integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS original_height integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS original_format varchar(10) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS metadata jsonb NOT NULL DEFAULT '{}';
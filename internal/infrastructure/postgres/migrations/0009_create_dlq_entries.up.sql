@@ -0,0 +1,22 @@
+-- Image tasks that failed for good, kept for querying and resolution. Entries
+-- outlive their product, so product_id is not a foreign key.
+CREATE TABLE IF NOT EXISTS dlq_entries (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id uuid NOT NULL,
+    error_class varchar(50) NOT NULL,
+    error text NOT NULL,
+    failed_urls jsonb NOT NULL DEFAULT '[]',
+    partial_results jsonb NOT NULL DEFAULT '[]',
+    attempts integer NOT NULL,
+    task jsonb NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'open',
+    resolution text NOT NULL DEFAULT '',
+    resolved_at timestamptz,
+    failed_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dlq_entries_failed_at ON dlq_entries (failed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_dlq_entries_product_id ON dlq_entries (product_id, failed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_dlq_entries_open ON dlq_entries (error_class, failed_at DESC) WHERE status = 'open';
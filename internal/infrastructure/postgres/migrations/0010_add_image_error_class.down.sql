@@ -0,0 +1 @@
+ALTER TABLE product_image_statuses DROP COLUMN IF EXISTS error_class;
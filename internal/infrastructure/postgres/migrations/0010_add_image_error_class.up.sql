@@ -0,0 +1,7 @@
+-- Class of the last error of each image, see model.ErrorClass
+ALTER TABLE product_image_statuses ADD COLUMN IF NOT EXISTS error_class varchar(30) NOT NULL DEFAULT '';
+
+-- Earlier DLQ entries were classified by processing stage
+UPDATE dlq_entries SET error_class = 'db_failure' WHERE error_class = 'product_update';
+UPDATE dlq_entries SET error_class = 'storage_failure' WHERE error_class = 'upload';
+UPDATE dlq_entries SET error_class = 'unknown' WHERE error_class IN ('download', 'decode', 'encode');
@@ -0,0 +1,3 @@
+ALTER TABLE product_image_statuses DROP COLUMN IF EXISTS digest;
+DROP TABLE IF EXISTS processed_image_references;
+DROP TABLE IF EXISTS processed_images;
@@ -0,0 +1,27 @@
+-- Renditions and variants produced from a source image, by digest of its
+-- content and of the processing settings, so identical images are processed once
+CREATE TABLE IF NOT EXISTS processed_images (
+    digest varchar(64) PRIMARY KEY,
+    renditions jsonb NOT NULL DEFAULT '{}',
+    variants jsonb NOT NULL DEFAULT '[]',
+    original_width integer NOT NULL DEFAULT 0,
+    original_height integer NOT NULL DEFAULT 0,
+    original_format varchar(10) NOT NULL DEFAULT '',
+    metadata jsonb NOT NULL DEFAULT '{}',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Products using each processed image; its objects are deleted once the last
+-- reference is released
+CREATE TABLE IF NOT EXISTS processed_image_references (
+    digest varchar(64) NOT NULL REFERENCES processed_images (digest) ON DELETE CASCADE,
+    product_id uuid NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (digest, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_image_references_product_id ON processed_image_references (product_id);
+
+-- Digest of the processed image each source image produced
+ALTER TABLE product_image_statuses ADD COLUMN IF NOT EXISTS digest varchar(64) NOT NULL DEFAULT '';
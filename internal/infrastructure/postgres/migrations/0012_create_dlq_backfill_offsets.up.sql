@@ -0,0 +1,7 @@
+-- Next offset of each partition of the DLQ topic to import into dlq_entries,
+-- so every message is imported once even after its entry is purged
+CREATE TABLE IF NOT EXISTS dlq_backfill_offsets (
+    topic_partition integer PRIMARY KEY,
+    next_offset bigint NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,58 @@
+// internal/infrastructure/postgres/object_reference_repository.go
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// Compressed images hold null for failed images and older rows may hold
+// JSON null, hence the type checks. Soft-deleted products are read too: their
+// images are kept until they are purged. Processed images no product
+// references are left over from a failed release and are collected
+const listObjectReferencesQuery = `SELECT DISTINCT ref FROM (
+	SELECT jsonb_array_elements_text(product_images) AS ref FROM products
+	UNION ALL
+	SELECT rendition.value FROM products,
+		jsonb_array_elements(CASE WHEN jsonb_typeof(compressed_product_images) = 'array' THEN compressed_product_images ELSE '[]' END) AS image,
+		jsonb_each_text(CASE WHEN jsonb_typeof(image) = 'object' THEN image ELSE '{}' END) AS rendition
+	UNION ALL
+	SELECT COALESCE(NULLIF(variant->>'key', ''), variant->>'url') FROM products,
+		jsonb_array_elements(CASE WHEN jsonb_typeof(image_variants) = 'array' THEN image_variants ELSE '[]' END) AS image,
+		jsonb_array_elements(CASE WHEN jsonb_typeof(image) = 'array' THEN image ELSE '[]' END) AS variant
+	UNION ALL
+	SELECT source_url FROM product_image_statuses
+	UNION ALL
+	SELECT rendition.value FROM product_image_statuses, jsonb_each_text(renditions) AS rendition
+	UNION ALL
+	SELECT COALESCE(NULLIF(variant->>'key', ''), variant->>'url') FROM product_image_statuses,
+		jsonb_array_elements(variants) AS variant
+	UNION ALL
+	SELECT COALESCE(NULLIF(variant->>'key', ''), variant->>'url') FROM processed_images,
+		jsonb_array_elements(variants) AS variant
+	WHERE EXISTS (SELECT 1 FROM processed_image_references r WHERE r.digest = processed_images.digest)
+	UNION ALL
+	SELECT jsonb_array_elements_text(task->'image_urls') FROM dlq_entries
+) refs
+WHERE ref IS NOT NULL AND ref <> ''`
+
+type ObjectReferenceRepo struct {
+	DB *gorm.DB
+}
+
+func NewObjectReferenceRepo(db *gorm.DB) repository.ObjectReferenceRepository {
+	return &ObjectReferenceRepo{
+		DB: db,
+	}
+}
+
+func (r *ObjectReferenceRepo) List(ctx context.Context) ([]string, error) {
+	var refs []string
+	if err := r.DB.WithContext(ctx).Raw(listObjectReferencesQuery).Scan(&refs).Error; err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
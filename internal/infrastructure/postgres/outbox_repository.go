@@ -0,0 +1,74 @@
+// internal/infrastructure/postgres/outbox_repository.go
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+const claimOutboxQuery = `UPDATE outbox SET locked_until = now() + make_interval(secs => ?)
+WHERE id IN (
+	SELECT o.id FROM outbox o
+	WHERE o.topic = ?
+		AND o.delivered_at IS NULL
+		AND o.next_attempt_at <= now()
+		AND (o.locked_until IS NULL OR o.locked_until < now())
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox earlier
+			WHERE earlier.aggregate_id = o.aggregate_id
+				AND earlier.delivered_at IS NULL
+				AND earlier.id < o.id
+		)
+	ORDER BY o.id
+	LIMIT ?
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+type OutboxRepo struct {
+	DB *gorm.DB
+}
+
+func NewOutboxRepo(db *gorm.DB) repository.OutboxRepository {
+	return &OutboxRepo{
+		DB: db,
+	}
+}
+
+func (r *OutboxRepo) ClaimPending(ctx context.Context, topic string, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+	if err := r.DB.WithContext(ctx).Raw(claimOutboxQuery, lease.Seconds(), topic, limit).Scan(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *OutboxRepo) MarkDelivered(ctx context.Context, id int64) error {
+	return r.DB.WithContext(ctx).Model(&model.OutboxMessage{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"delivered_at": time.Now(),
+			"locked_until": nil,
+		}).Error
+}
+
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error {
+	return r.DB.WithContext(ctx).Model(&model.OutboxMessage{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      reason,
+			"next_attempt_at": nextAttemptAt,
+			"locked_until":    nil,
+		}).Error
+}
+
+func (r *OutboxRepo) DeleteDelivered(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).
+		Where("delivered_at IS NOT NULL AND delivered_at < ?", before).
+		Delete(&model.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
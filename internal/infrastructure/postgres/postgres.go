@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/iSparshP/product-management-system/internal/domain/model"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -25,6 +24,8 @@ func BuildDSN(cfg *Config) string {
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName)
 }
 
+// NewPostgresDB connects to PostgreSQL. The schema is managed by the embedded
+// migrations, see Migrator.
 func NewPostgresDB(dsn string) *gorm.DB {
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
@@ -33,13 +34,5 @@ func NewPostgresDB(dsn string) *gorm.DB {
 		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
 	}
 
-	// Enable UUID extension
-	db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";")
-
-	// Auto migrate models
-	if err := db.AutoMigrate(&model.Product{}, &model.User{}); err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
-	}
-
 	return db
 }
@@ -0,0 +1,152 @@
+// internal/infrastructure/postgres/processed_image_repository.go
+
+package postgres
+
+import (
+	"context"
+	"slices"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// The share lock keeps a concurrent Release from deleting the image until the
+// reference is committed
+const lockProcessedImageQuery = `SELECT * FROM processed_images WHERE digest = ? FOR SHARE`
+
+// References of products purged meanwhile are not added
+const insertProcessedImageReferenceQuery = `INSERT INTO processed_image_references (digest, product_id)
+SELECT ?, id FROM products WHERE id = ?
+ON CONFLICT DO NOTHING`
+
+// The first image saved for a digest wins; the others read it back
+const saveProcessedImageQuery = `INSERT INTO processed_images
+	(digest, renditions, variants, original_width, original_height, original_format, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (digest) DO NOTHING`
+
+// Only replaces the variants the caller found missing, not those a concurrent
+// run stored meanwhile
+const replaceProcessedImageQuery = `UPDATE processed_images SET
+	renditions = ?,
+	variants = ?,
+	original_width = ?,
+	original_height = ?,
+	original_format = ?,
+	metadata = ?,
+	updated_at = now()
+WHERE digest = ? AND variants = ?::jsonb`
+
+type ProcessedImageRepo struct {
+	DB *gorm.DB
+}
+
+func NewProcessedImageRepo(db *gorm.DB) repository.ProcessedImageRepository {
+	return &ProcessedImageRepo{
+		DB: db,
+	}
+}
+
+func (r *ProcessedImageRepo) Acquire(ctx context.Context, digest, productID string) (*model.ProcessedImage, error) {
+	var image model.ProcessedImage
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Raw(lockProcessedImageQuery, digest).Scan(&image)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrProcessedImageNotFound
+		}
+		return tx.Exec(insertProcessedImageReferenceQuery, digest, productID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *ProcessedImageRepo) Save(ctx context.Context, image *model.ProcessedImage, productID string) (*model.ProcessedImage, error) {
+	var stored model.ProcessedImage
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(saveProcessedImageQuery, image.Digest, image.Renditions, image.Variants,
+			image.OriginalWidth, image.OriginalHeight, image.OriginalFormat, image.Metadata).Error; err != nil {
+			return err
+		}
+		// The image of a concurrent run may have been released meanwhile
+		result := tx.Raw(lockProcessedImageQuery, image.Digest).Scan(&stored)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrProcessedImageNotFound
+		}
+		return tx.Exec(insertProcessedImageReferenceQuery, image.Digest, productID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (r *ProcessedImageRepo) Replace(ctx context.Context, image *model.ProcessedImage, staleVariants datatypes.JSON) (bool, error) {
+	result := r.DB.WithContext(ctx).Exec(replaceProcessedImageQuery, image.Renditions, image.Variants,
+		image.OriginalWidth, image.OriginalHeight, image.OriginalFormat, image.Metadata,
+		image.Digest, staleVariants)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *ProcessedImageRepo) Release(ctx context.Context, productID string, keep []string) ([]model.ProcessedImage, error) {
+	var released []model.ProcessedImage
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&model.ProcessedImageReference{}).Where("product_id = ?", productID)
+		if len(keep) > 0 {
+			query = query.Where("digest NOT IN ?", keep)
+		}
+		var digests []string
+		if err := query.Pluck("digest", &digests).Error; err != nil {
+			return err
+		}
+		if len(digests) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("product_id = ? AND digest IN ?", productID, digests).
+			Delete(&model.ProcessedImageReference{}).Error; err != nil {
+			return err
+		}
+
+		// Lock the images before counting their references: an Acquire holds
+		// its lock until its reference is visible to the count
+		var images []model.ProcessedImage
+		if err := tx.Raw("SELECT * FROM processed_images WHERE digest IN ? ORDER BY digest FOR UPDATE", digests).
+			Scan(&images).Error; err != nil {
+			return err
+		}
+		var referenced []string
+		if err := tx.Model(&model.ProcessedImageReference{}).Where("digest IN ?", digests).
+			Distinct().Pluck("digest", &referenced).Error; err != nil {
+			return err
+		}
+
+		var unreferenced []string
+		for _, image := range images {
+			if !slices.Contains(referenced, image.Digest) {
+				released = append(released, image)
+				unreferenced = append(unreferenced, image.Digest)
+			}
+		}
+		if len(unreferenced) == 0 {
+			return nil
+		}
+		return tx.Where("digest IN ?", unreferenced).Delete(&model.ProcessedImage{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
@@ -4,13 +4,29 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/iSparshP/product-management-system/internal/domain/model"
 	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"github.com/iSparshP/product-management-system/pkg/utils"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
+// Full-text search expressions over the generated search_vector column.
+// The rank adds trigram word similarity so near-miss spellings still score.
+// The description is HTML-escaped before highlighting so that the <mark> tags
+// are the only markup of the snippet; the parser keeps entities whole.
+const (
+	searchQueryExpr       = "websearch_to_tsquery('english', ?)"
+	searchRankExpr        = "(ts_rank_cd(search_vector, " + searchQueryExpr + ") + word_similarity(?, product_name))::float8"
+	searchDescriptionExpr = `replace(replace(replace(replace(replace(coalesce(product_description, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+	searchSnippetExpr     = "ts_headline('english', " + searchDescriptionExpr + ", " + searchQueryExpr + ", 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10')"
+)
+
 type ProductRepo struct {
 	DB *gorm.DB
 }
@@ -21,45 +37,272 @@ func NewProductRepo(db *gorm.DB) repository.ProductRepository {
 	}
 }
 
-func (r *ProductRepo) Create(ctx context.Context, product *model.Product) error {
-	return r.DB.WithContext(ctx).Create(product).Error
+func (r *ProductRepo) Create(ctx context.Context, product *model.Product, outbox ...model.OutboxMessage) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		return createOutboxMessages(tx, outbox)
+	})
 }
 
 func (r *ProductRepo) GetByID(ctx context.Context, id string) (*model.Product, error) {
 	var product model.Product
 	if err := r.DB.WithContext(ctx).First(&product, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, err
+			return nil, repository.ErrProductNotFound
 		}
 		return nil, err
 	}
 	return &product, nil
 }
 
-func (r *ProductRepo) GetAll(ctx context.Context, userID string, filters map[string]interface{}) ([]model.Product, error) {
+func (r *ProductRepo) GetAll(ctx context.Context, userID string, filter model.ProductFilter, page model.PageRequest) (*model.ProductPage, error) {
 	var products []model.Product
-	query := r.DB.WithContext(ctx).Where("user_id = ?", userID)
+	query := applyProductFilter(r.DB.WithContext(ctx).Where("user_id = ?", userID), filter)
 
-	if name, ok := filters["name"].(string); ok && name != "" {
-		query = query.Where("product_name ILIKE ?", "%"+name+"%")
+	// Keyset pagination: continue strictly after the (sort value, id) of the cursor
+	column, orderColumn := sortColumns[page.Sort], sortColumns[page.Sort]
+	var columnArgs []interface{}
+	if filter.Search != "" {
+		query = query.Select("products.*, "+searchRankExpr+" AS search_rank, "+searchSnippetExpr+" AS search_snippet",
+			filter.Search, filter.Search, filter.Search)
+	}
+	if page.Sort == model.SortByRelevance {
+		if filter.Search == "" {
+			return nil, fmt.Errorf("sorting by %s requires a search query", model.SortByRelevance)
+		}
+		column, orderColumn = searchRankExpr, "search_rank"
+		columnArgs = []interface{}{filter.Search, filter.Search}
 	}
 
-	if minPrice, ok := filters["min_price"].(float64); ok && minPrice > 0 {
-		query = query.Where("product_price >= ?", minPrice)
+	direction, comparison := "ASC", ">"
+	if page.Order == model.SortDesc {
+		direction, comparison = "DESC", "<"
 	}
 
-	if maxPrice, ok := filters["max_price"].(float64); ok && maxPrice > 0 {
-		query = query.Where("product_price <= ?", maxPrice)
+	if page.Cursor != "" {
+		value, id, err := decodeCursor(page)
+		if err != nil {
+			return nil, err
+		}
+		placeholder := "?"
+		if page.Sort == model.SortByPrice {
+			// Compare as numeric so the index on product_price can be used
+			placeholder = "CAST(? AS numeric)"
+		}
+		query = query.Where(fmt.Sprintf("(%s, id) %s (%s, ?)", column, comparison, placeholder), append(columnArgs, value, id)...)
 	}
 
+	query = query.Order(fmt.Sprintf("%s %s, id %s", orderColumn, direction, direction)).Limit(page.Limit + 1)
+
 	if err := query.Find(&products).Error; err != nil {
 		return nil, err
 	}
 
+	result := &model.ProductPage{Data: products}
+	if len(products) > page.Limit {
+		result.Data = products[:page.Limit]
+		cursor, err := encodeCursor(page, &result.Data[page.Limit-1])
+		if err != nil {
+			return nil, err
+		}
+		result.NextCursor = cursor
+	}
+
+	return result, nil
+}
+
+func applyProductFilter(query *gorm.DB, filter model.ProductFilter) *gorm.DB {
+	if filter.Search != "" {
+		// Full-text match, or a typo-tolerant trigram match on the name
+		query = query.Where("(search_vector @@ "+searchQueryExpr+" OR ? <% product_name)", filter.Search, filter.Search)
+	}
+	if filter.Name != "" {
+		query = query.Where("product_name ILIKE ?", "%"+filter.Name+"%")
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("product_price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("product_price <= ?", *filter.MaxPrice)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		query = query.Where("updated_at >= ?", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		query = query.Where("updated_at < ?", *filter.UpdatedBefore)
+	}
+	if filter.HasCompressedImages != nil {
+		if *filter.HasCompressedImages {
+			query = query.Where("jsonb_array_length(coalesce(compressed_product_images, '[]'::jsonb)) > 0")
+		} else {
+			query = query.Where("jsonb_array_length(coalesce(compressed_product_images, '[]'::jsonb)) = 0")
+		}
+	}
+	if len(filter.IDs) > 0 {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	return query
+}
+
+// Update writes the editable fields of a product if it is still at the
+// expected version, and advances the version.
+func (r *ProductRepo) Update(ctx context.Context, product *model.Product, expectedVersion int64, outbox ...model.OutboxMessage) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Product{}).
+			Where("id = ? AND version = ?", product.ID, expectedVersion).
+			Updates(map[string]interface{}{
+				"product_name":              product.ProductName,
+				"product_description":       product.ProductDescription,
+				"product_images":            product.ProductImages,
+				"compressed_product_images": product.CompressedProductImages,
+				"image_variants":            product.ImageVariants,
+				"image_processing_status":   product.ImageProcessingStatus,
+				"product_price":             product.ProductPrice,
+				"updated_at":                product.UpdatedAt,
+				"version":                   gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return r.missingOrConflict(ctx, product.ID.String())
+		}
+		return createOutboxMessages(tx, outbox)
+	})
+	if err != nil {
+		return err
+	}
+
+	product.Version = expectedVersion + 1
+	return nil
+}
+
+// Delete moves a product to the trash if it is still at the expected version;
+// it is removed for good by Purge.
+func (r *ProductRepo) Delete(ctx context.Context, id string, expectedVersion int64) error {
+	result := r.DB.WithContext(ctx).
+		Where("id = ? AND version = ?", id, expectedVersion).
+		Delete(&model.Product{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOrConflict(ctx, id)
+	}
+	return nil
+}
+
+func createOutboxMessages(tx *gorm.DB, outbox []model.OutboxMessage) error {
+	if len(outbox) == 0 {
+		return nil
+	}
+	return tx.Create(&outbox).Error
+}
+
+// missingOrConflict tells apart why a versioned write matched no rows.
+func (r *ProductRepo) missingOrConflict(ctx context.Context, id string) error {
+	var count int64
+	if err := r.DB.WithContext(ctx).Model(&model.Product{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return repository.ErrVersionConflict
+	}
+	return repository.ErrProductNotFound
+}
+
+func (r *ProductRepo) GetTrash(ctx context.Context, userID string) ([]model.Product, error) {
+	var products []model.Product
+	if err := r.DB.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
-func (r *ProductRepo) UpdateCompressedImages(ctx context.Context, id string, images []string) error {
-	return r.DB.WithContext(ctx).Model(&model.Product{}).Where("id = ?", id).
-		Update("compressed_product_images", images).Error
+func (r *ProductRepo) Restore(ctx context.Context, id string) error {
+	result := r.DB.WithContext(ctx).Unscoped().Model(&model.Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrProductNotFound
+	}
+	return nil
+}
+
+func (r *ProductRepo) GetTrashedBefore(ctx context.Context, cutoff time.Time, limit int) ([]model.Product, error) {
+	var products []model.Product
+	if err := r.DB.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("deleted_at").
+		Limit(limit).
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *ProductRepo) Purge(ctx context.Context, id string) error {
+	result := r.DB.WithContext(ctx).Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Delete(&model.Product{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrProductNotFound
+	}
+	return nil
+}
+
+// UpdateImageProcessingStatus records the progress of the image processor
+// for the given source images; like UpdateCompressedImages it leaves products
+// whose images were edited meanwhile alone.
+func (r *ProductRepo) UpdateImageProcessingStatus(ctx context.Context, id string, sourceImages []string, status model.ImageProcessingStatus) error {
+	return r.updateProcessedImages(ctx, id, sourceImages, map[string]interface{}{
+		"image_processing_status": status,
+	})
+}
+
+// UpdateCompressedImages stores the compressed images only if the product
+// still has the source images they were made from, so results of a stale
+// task never overwrite images of a newer edit.
+func (r *ProductRepo) UpdateCompressedImages(ctx context.Context, id string, sourceImages []string, result model.ImageProcessingResult) error {
+	variants, err := json.Marshal(result.Variants)
+	if err != nil {
+		return err
+	}
+
+	return r.updateProcessedImages(ctx, id, sourceImages, map[string]interface{}{
+		"compressed_product_images": utils.RenditionsToJSON(result.CompressedImages),
+		"image_variants":            datatypes.JSON(variants),
+		"image_processing_status":   result.Status,
+	})
+}
+
+func (r *ProductRepo) updateProcessedImages(ctx context.Context, id string, sourceImages []string, updates map[string]interface{}) error {
+	updates["version"] = gorm.Expr("version + 1")
+	result := r.DB.WithContext(ctx).Model(&model.Product{}).
+		Where("id = ? AND product_images = CAST(? AS jsonb)", id, string(utils.StringSliceToJSON(sourceImages))).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrSourceImagesChanged
+	}
+	return nil
 }
@@ -74,3 +74,8 @@ func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
 	n, err := c.Client.Exists(ctx, key).Result()
 	return n > 0, err
 }
+
+// Incr increments the integer value of a key, starting from zero
+func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
+	return c.Client.Incr(ctx, key).Result()
+}
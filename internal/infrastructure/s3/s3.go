@@ -3,29 +3,49 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
 	"go.uber.org/zap"
 )
 
+// multipartPartSize is the size of the parts of multipart uploads; smaller
+// objects are uploaded in one request. S3 parts are at least 5 MiB.
+const multipartPartSize = 8 << 20
+
+// Client is the S3 object store.
 type Client struct {
 	s3Client *s3.Client
 	bucket   string
-	logger   *zap.Logger
+	// baseURL is the URL of the bucket, objects are found below it
+	baseURL *url.URL
+	logger  *zap.Logger
 }
 
-func NewS3Client(accessKey, secretKey, region, bucket, endpoint string, logger *zap.Logger) *Client {
+// NewS3Client creates the S3 store. An empty endpoint is AWS itself; other
+// endpoints, such as MinIO's, usually need pathStyle.
+func NewS3Client(accessKey, secretKey, region, bucket, endpoint string, pathStyle bool, logger *zap.Logger) *Client {
 	logger.Info("Initializing S3 client",
 		zap.String("region", region),
 		zap.String("bucket", bucket),
 		zap.String("endpoint", endpoint),
+		zap.Bool("pathStyle", pathStyle),
 		zap.Bool("hasAccessKey", accessKey != ""),
 		zap.Bool("hasSecretKey", secretKey != ""))
 
@@ -46,8 +66,14 @@ func NewS3Client(accessKey, secretKey, region, bucket, endpoint string, logger *
 		if endpoint != "" {
 			o.BaseEndpoint = aws.String(endpoint)
 		}
+		o.UsePathStyle = pathStyle
 	})
 
+	baseURL, err := bucketURL(endpoint, region, bucket, pathStyle)
+	if err != nil {
+		logger.Fatal("Invalid S3 endpoint", zap.String("endpoint", endpoint), zap.Error(err))
+	}
+
 	// Test the credentials
 	_, err = s3Client.ListBuckets(context.Background(), &s3.ListBucketsInput{})
 	if err != nil {
@@ -59,62 +85,314 @@ func NewS3Client(accessKey, secretKey, region, bucket, endpoint string, logger *
 	return &Client{
 		s3Client: s3Client,
 		bucket:   bucket,
+		baseURL:  baseURL,
 		logger:   logger,
 	}
 }
 
-func (c *Client) UploadFile(ctx context.Context, filePath, fileName string) (string, error) {
-	f, err := os.Open(filePath)
+// bucketURL returns the URL of a bucket, addressed by path or by virtual host.
+func bucketURL(endpoint, region, bucket string, pathStyle bool) (*url.URL, error) {
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	u, err := url.Parse(endpoint)
 	if err != nil {
-		c.logger.Error("Failed to open file for S3 upload",
-			zap.Error(err),
-			zap.String("file_path", filePath))
-		return "", fmt.Errorf("failed to open file: %w", err)
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("endpoint %q is not an absolute URL", endpoint)
 	}
-	defer f.Close()
 
-	// Generate a unique key for the file
-	key := fmt.Sprintf("products/%s", filepath.Base(fileName))
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if pathStyle {
+		u.Path += "/" + bucket
+	} else {
+		u.Host = bucket + "." + u.Host
+	}
+	return u, nil
+}
 
-	// Upload the file
-	_, err = c.s3Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(c.bucket),
-		Key:         aws.String(key),
-		Body:        f,
-		ContentType: aws.String(getContentType(fileName)),
-	})
+// partPool holds the part buffers of multipart uploads
+var partPool = sync.Pool{
+	New: func() any {
+		part := make([]byte, multipartPartSize)
+		return &part
+	},
+}
+
+// Put uploads bodies of up to one part in a single request and larger ones
+// in parts, so that at most one part is held in memory. Small bodies only
+// take the memory they need; larger ones share pooled part buffers. S3
+// verifies the SHA-256 of each request, and keeps it with the object.
+func (c *Client) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	if err := storage.ValidateKey(key); err != nil {
+		return err
+	}
+
+	var first bytes.Buffer
+	_, err := io.CopyN(&first, body, multipartPartSize)
+	switch {
+	case errors.Is(err, io.EOF):
+		err = c.putObject(ctx, key, first.Bytes(), contentType)
+	case err == nil:
+		part := partPool.Get().(*[]byte)
+		defer partPool.Put(part)
+		err = c.putMultipart(ctx, key, body, first.Bytes(), *part, contentType)
+	default:
+		err = fmt.Errorf("failed to read object: %w", err)
+	}
 	if err != nil {
 		c.logger.Error("Failed to upload file to S3",
 			zap.Error(err),
 			zap.String("bucket", c.bucket),
 			zap.String("key", key))
-		return "", fmt.Errorf("failed to upload file: %w", err)
+		return fmt.Errorf("failed to upload file: %w", err)
 	}
 
-	// Generate the S3 URL
-	url := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", c.bucket, "ap-south-1", key)
-
 	c.logger.Info("Successfully uploaded file to S3",
 		zap.String("bucket", c.bucket),
-		zap.String("key", key),
-		zap.String("url", url))
-
-	return url, nil
-}
-
-// getContentType determines the content type based on file extension
-func getContentType(fileName string) string {
-	ext := filepath.Ext(fileName)
-	switch ext {
-	case ".jpg", ".jpeg":
-		return "image/jpeg"
-	case ".png":
-		return "image/png"
-	case ".gif":
-		return "image/gif"
-	case ".webp":
-		return "image/webp"
+		zap.String("key", key))
+
+	return nil
+}
+
+func (c *Client) putObject(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := c.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:            aws.String(c.bucket),
+		Key:               aws.String(key),
+		Body:              bytes.NewReader(data),
+		ContentLength:     aws.Int64(int64(len(data))),
+		ContentType:       aws.String(contentType),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+		ChecksumSHA256:    aws.String(checksumSHA256(data)),
+	})
+	return err
+}
+
+// putMultipart uploads the first part read already, then the rest of the
+// body one part at a time. The object is only checksummed part by part.
+func (c *Client) putMultipart(ctx context.Context, key string, body io.Reader, first, part []byte, contentType string) error {
+	upload, err := c.s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:            aws.String(c.bucket),
+		Key:               aws.String(key),
+		ContentType:       aws.String(contentType),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+
+	parts, err := c.uploadParts(ctx, key, upload.UploadId, body, first, part)
+	if err == nil {
+		_, err = c.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(c.bucket),
+			Key:             aws.String(key),
+			UploadId:        upload.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+	}
+	if err != nil {
+		// Abort even if ctx is done, or the parts are kept and billed
+		if _, abortErr := c.s3Client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(c.bucket),
+			Key:      aws.String(key),
+			UploadId: upload.UploadId,
+		}); abortErr != nil {
+			c.logger.Warn("Failed to abort multipart upload",
+				zap.String("key", key),
+				zap.Error(abortErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// uploadParts uploads first, then reads the rest of the body into part and
+// uploads it one part at a time.
+func (c *Client) uploadParts(ctx context.Context, key string, uploadID *string, body io.Reader, first, part []byte) ([]types.CompletedPart, error) {
+	var parts []types.CompletedPart
+	data := first
+	for partNumber := int32(1); len(data) > 0; partNumber++ {
+		out, err := c.s3Client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:            aws.String(c.bucket),
+			Key:               aws.String(key),
+			UploadId:          uploadID,
+			PartNumber:        aws.Int32(partNumber),
+			Body:              bytes.NewReader(data),
+			ContentLength:     aws.Int64(int64(len(data))),
+			ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+			ChecksumSHA256:    aws.String(checksumSHA256(data)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+		}
+		parts = append(parts, types.CompletedPart{
+			ETag:           out.ETag,
+			PartNumber:     aws.Int32(partNumber),
+			ChecksumSHA256: out.ChecksumSHA256,
+		})
+
+		n, err := io.ReadFull(body, part)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("failed to read object: %w", err)
+		}
+		data = part[:n]
+	}
+	return parts, nil
+}
+
+// Get asks for the stored checksum, which the SDK also verifies the body
+// against as it is read.
+func (c *Client) Get(ctx context.Context, key string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	out, err := c.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:       aws.String(c.bucket),
+		Key:          aws.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil, storage.ErrObjectNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	return out.Body, &storage.ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		LastModified: aws.ToTime(out.LastModified),
+		Checksum:     objectChecksum(out.ChecksumSHA256, out.Metadata),
+	}, nil
+}
+
+// checksumSHA256 returns the base64 SHA-256 S3 expects of a request body.
+func checksumSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// objectChecksum returns the hex SHA-256 of an object. Multipart objects only
+// have a checksum of their part checksums, suffixed with the part count, and
+// objects stored before S3 checksums were used keep theirs in metadata.
+func objectChecksum(native *string, metadata map[string]string) string {
+	if sum, err := base64.StdEncoding.DecodeString(aws.ToString(native)); err == nil && len(sum) == sha256.Size {
+		return hex.EncodeToString(sum)
+	}
+	return metadata[storage.ChecksumMetadataKey]
+}
+
+func (c *Client) Delete(ctx context.Context, key string) error {
+	_, err := c.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		c.logger.Error("Failed to delete file from S3",
+			zap.Error(err),
+			zap.String("bucket", c.bucket),
+			zap.String("key", key))
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	c.logger.Info("Successfully deleted file from S3",
+		zap.String("bucket", c.bucket),
+		zap.String("key", key))
+
+	return nil
+}
+
+func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := c.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check file: %w", err)
+	}
+	return true, nil
+}
+
+func (c *Client) List(ctx context.Context, prefix string, fn func(storage.ObjectInfo) error) error {
+	paginator := s3.NewListObjectsV2Paginator(c.s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+		for _, object := range page.Contents {
+			key := aws.ToString(object.Key)
+			if err := fn(storage.ObjectInfo{
+				Key:          key,
+				Size:         aws.ToInt64(object.Size),
+				ContentType:  storage.ContentType(key),
+				LastModified: aws.ToTime(object.LastModified),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) URL(key string) string {
+	u := *c.baseURL
+	u.Path += "/" + key
+	return u.String()
+}
+
+func (c *Client) PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	req, err := s3.NewPresignClient(c.s3Client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign file URL: %w", err)
+	}
+	return req.URL, nil
+}
+
+func (c *Client) PresignedPutURL(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (string, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return "", err
+	}
+	// Content type and length are signed, so the upload must match them
+	req, err := s3.NewPresignClient(c.s3Client).PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(c.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign upload URL: %w", err)
+	}
+	return req.URL, nil
+}
+
+// KeyFromURL extracts the object key from a URL of the bucket. Besides URLs
+// of the configured style, it accepts the virtual-hosted URLs stored before
+// URLs were configurable, whatever their region.
+func (c *Client) KeyFromURL(fileURL string) (string, bool) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return "", false
+	}
+
+	var key string
+	switch {
+	case u.Host == c.baseURL.Host && strings.HasPrefix(u.Path, c.baseURL.Path+"/"):
+		key = strings.TrimPrefix(u.Path, c.baseURL.Path+"/")
+	case strings.HasPrefix(u.Host, c.bucket+".s3.") && strings.HasSuffix(u.Host, ".amazonaws.com"):
+		key = strings.TrimPrefix(u.Path, "/")
 	default:
-		return "application/octet-stream"
+		return "", false
 	}
+	return key, key != ""
 }
@@ -0,0 +1,306 @@
+// internal/usecase/dlq/usecase.go
+
+package dlq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/kafka"
+	"github.com/iSparshP/product-management-system/pkg/utils"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNoSelection is returned when a replay or resolution names no
+	// entries and does not ask for all of them.
+	ErrNoSelection = errors.New("select entries by id or ask for all of them")
+	// ErrInvalidFilter is returned for filters that cannot match any entry.
+	ErrInvalidFilter = errors.New("invalid DLQ filter")
+)
+
+// Usecase queries the failed image tasks, replays them onto the image
+// processing topic, resolves those that need no replay and purges them.
+type Usecase interface {
+	List(ctx context.Context, filter model.DLQFilter) ([]model.DLQEntry, error)
+	Summarize(ctx context.Context, filter model.DLQFilter) ([]model.DLQSummary, error)
+	Get(ctx context.Context, id string) (*model.DLQEntry, error)
+	Replay(ctx context.Context, req model.DLQReplayRequest) (*model.DLQReplayResult, error)
+	// Resolve sets the status of the selected entries and returns how many
+	// were changed.
+	Resolve(ctx context.Context, req model.DLQResolveRequest) (int64, error)
+	// Purge deletes the selected entries and returns how many were deleted.
+	Purge(ctx context.Context, req model.DLQPurgeRequest) (int64, error)
+	// Backfill imports the messages of the DLQ topic published before
+	// failures were recorded as entries.
+	Backfill(ctx context.Context) (*model.DLQBackfillResult, error)
+}
+
+type usecase struct {
+	repo      repository.DLQRepository
+	publisher *kafka.Publisher
+	reader    *kafka.DLQReader
+	logger    *zap.Logger
+}
+
+// NewDLQUsecase creates the DLQ usecase; publisher must publish to the image
+// processing topic and reader read the DLQ topic.
+func NewDLQUsecase(repo repository.DLQRepository, publisher *kafka.Publisher, reader *kafka.DLQReader, logger *zap.Logger) Usecase {
+	return &usecase{
+		repo:      repo,
+		publisher: publisher,
+		reader:    reader,
+		logger:    logger,
+	}
+}
+
+func (u *usecase) List(ctx context.Context, filter model.DLQFilter) ([]model.DLQEntry, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	return u.repo.List(ctx, filter)
+}
+
+func (u *usecase) Summarize(ctx context.Context, filter model.DLQFilter) ([]model.DLQSummary, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	return u.repo.Summarize(ctx, filter)
+}
+
+func (u *usecase) Get(ctx context.Context, id string) (*model.DLQEntry, error) {
+	return u.repo.Get(ctx, id)
+}
+
+func (u *usecase) Replay(ctx context.Context, req model.DLQReplayRequest) (*model.DLQReplayResult, error) {
+	// Replaying all entries only considers the open ones
+	req.Filter.Status = model.DLQStatusOpen
+	entries, err := u.selectEntries(ctx, req.IDs, req.All, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.DLQReplayResult{Replayed: []string{}, Skipped: []string{}}
+	for _, entry := range entries {
+		// Claim the entry first, so concurrent replays cannot both publish it
+		id := entry.ID.String()
+		claimed, err := u.repo.MarkReplayed(ctx, id, time.Now())
+		if err != nil {
+			return result, fmt.Errorf("failed to mark entry %s replayed: %w", id, err)
+		}
+		if !claimed {
+			result.Skipped = append(result.Skipped, id)
+			continue
+		}
+
+		if err := u.publish(ctx, entry, req.OnlyFailed); err != nil {
+			if unmarkErr := u.repo.UnmarkReplayed(ctx, id); unmarkErr != nil {
+				u.logger.Error("Failed to release replay of DLQ entry",
+					zap.String("dlq_id", id),
+					zap.Error(unmarkErr))
+			}
+			return result, fmt.Errorf("failed to replay entry %s: %w", id, err)
+		}
+
+		u.logger.Info("Replayed DLQ entry",
+			zap.String("dlq_id", id),
+			zap.String("product_id", entry.ProductID.String()),
+			zap.Bool("only_failed", req.OnlyFailed))
+		result.Replayed = append(result.Replayed, id)
+	}
+	return result, nil
+}
+
+func (u *usecase) Resolve(ctx context.Context, req model.DLQResolveRequest) (int64, error) {
+	if req.Status != model.DLQStatusOpen && req.Status != model.DLQStatusIgnored {
+		return 0, fmt.Errorf("%w: entries can only be ignored or reopened", ErrInvalidFilter)
+	}
+	entries, err := u.selectEntries(ctx, req.IDs, req.All, req.Filter)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID.String()
+	}
+	resolved, err := u.repo.Resolve(ctx, ids, req.Status, req.Resolution, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	u.logger.Info("Resolved DLQ entries",
+		zap.Strings("dlq_ids", ids),
+		zap.String("status", string(req.Status)),
+		zap.String("resolution", req.Resolution))
+	return resolved, nil
+}
+
+func (u *usecase) Purge(ctx context.Context, req model.DLQPurgeRequest) (int64, error) {
+	entries, err := u.selectEntries(ctx, req.IDs, req.All, req.Filter)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID.String()
+	}
+	purged, err := u.repo.Purge(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	u.logger.Info("Purged DLQ entries", zap.Strings("dlq_ids", ids))
+	return purged, nil
+}
+
+// Backfill reads the DLQ topic from where the last backfill stopped, so a
+// message is never imported twice, even once its entry is purged. Messages
+// carrying an entry id were recorded when published. The others get the
+// state tracked in Redis before entries were recorded: purged messages are
+// skipped and replayed ones imported as replayed.
+func (u *usecase) Backfill(ctx context.Context) (*model.DLQBackfillResult, error) {
+	offsets, err := u.repo.BackfillOffsets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load backfill offsets: %w", err)
+	}
+
+	result := &model.DLQBackfillResult{}
+	err = u.reader.Read(ctx, offsets, func(record kafka.DLQRecord) error {
+		if err := u.backfillRecord(ctx, record, result); err != nil {
+			return err
+		}
+		return u.repo.SaveBackfillOffset(ctx, record.Partition, record.Offset+1)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	u.logger.Info("Backfilled DLQ entries",
+		zap.Int("imported", result.Imported),
+		zap.Int("recorded", result.Recorded),
+		zap.Int("skipped", result.Skipped))
+	return result, nil
+}
+
+func (u *usecase) backfillRecord(ctx context.Context, record kafka.DLQRecord, result *model.DLQBackfillResult) error {
+	if record.Message.ID != "" {
+		result.Recorded++
+		return nil
+	}
+	entry, ok := backfillEntry(record)
+	if record.Purged || !ok {
+		result.Skipped++
+		return nil
+	}
+
+	imported, err := u.repo.Import(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to import message %d-%d: %w", record.Partition, record.Offset, err)
+	}
+	if imported {
+		result.Imported++
+	} else {
+		result.Recorded++
+	}
+	return nil
+}
+
+// backfillEntry builds the entry of a DLQ message published before entries
+// were recorded. Its id derives from the message position, so importing it
+// again is a no-op.
+func backfillEntry(record kafka.DLQRecord) (*model.DLQEntry, bool) {
+	message := record.Message
+	productID, err := uuid.Parse(message.OriginalTask.ProductID)
+	if record.Invalid || err != nil {
+		return nil, false
+	}
+	task, err := json.Marshal(message.OriginalTask)
+	if err != nil {
+		return nil, false
+	}
+
+	// Messages published before failures were classified have no class
+	errorClass := message.ErrorClass
+	if errorClass == "" {
+		errorClass = model.ErrorClassUnknown
+	}
+	entry := &model.DLQEntry{
+		ID:             uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("kafka:%s/%d/%d", model.ImageProcessingDLQTopic, record.Partition, record.Offset))),
+		ProductID:      productID,
+		ErrorClass:     errorClass,
+		Error:          message.Error,
+		FailedURLs:     utils.StringSliceToJSON(append([]string{}, message.FailedURLs...)),
+		PartialResults: utils.StringSliceToJSON(append([]string{}, message.PartialResults...)),
+		Attempts:       message.RetryCount + 1,
+		Task:           task,
+		Status:         model.DLQStatusOpen,
+		FailedAt:       message.Timestamp,
+	}
+	if record.ReplayedAt != nil {
+		entry.Status = model.DLQStatusReplayed
+		entry.ResolvedAt = record.ReplayedAt
+	}
+	return entry, true
+}
+
+// selectEntries loads the entries listed in ids, or with all those matching
+// the filter.
+func (u *usecase) selectEntries(ctx context.Context, ids []string, all bool, filter model.DLQFilter) ([]model.DLQEntry, error) {
+	if all {
+		if err := validateFilter(filter); err != nil {
+			return nil, err
+		}
+		filter.Limit = 0
+		return u.repo.List(ctx, filter)
+	}
+	if len(ids) == 0 {
+		return nil, ErrNoSelection
+	}
+
+	entries := make([]model.DLQEntry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := u.repo.Get(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("entry %s: %w", id, err)
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// publish sends the original task of an entry back to the image processing
+// topic, keyed by product like every task.
+func (u *usecase) publish(ctx context.Context, entry model.DLQEntry, onlyFailed bool) error {
+	var task model.ImageProcessingTask
+	if err := json.Unmarshal(entry.Task, &task); err != nil {
+		return fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+	task.OnlyFailed = onlyFailed
+
+	payload, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+	return u.publisher.PublishWithKey(ctx, task.ProductID, payload)
+}
+
+// validateFilter rejects filters the database cannot compare against.
+func validateFilter(filter model.DLQFilter) error {
+	if filter.ProductID != "" {
+		if _, err := uuid.Parse(filter.ProductID); err != nil {
+			return fmt.Errorf("%w: product_id must be a UUID", ErrInvalidFilter)
+		}
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, filter.Status)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// internal/usecase/dlq/usecase_test.go
+
+package dlq
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/kafka"
+)
+
+func TestBackfillEntry(t *testing.T) {
+	failedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	replayedAt := failedAt.Add(time.Hour)
+	message := model.DLQMessage{
+		TaskID: "4f1c2a9e-6b1d-4c55-9a57-0f6c1d2e3b4a",
+		OriginalTask: model.ImageProcessingTask{
+			ProductID: "4f1c2a9e-6b1d-4c55-9a57-0f6c1d2e3b4a",
+			ImageURLs: []string{"https://example.com/a.jpg"},
+		},
+		Error:      "download failed",
+		Timestamp:  failedAt,
+		RetryCount: 2,
+	}
+
+	entry, ok := backfillEntry(kafka.DLQRecord{Partition: 1, Offset: 42, Message: message})
+	if !ok {
+		t.Fatal("message rejected")
+	}
+	if entry.ProductID.String() != message.OriginalTask.ProductID || entry.Attempts != 3 ||
+		entry.ErrorClass != model.ErrorClassUnknown || entry.Status != model.DLQStatusOpen ||
+		!entry.FailedAt.Equal(failedAt) {
+		t.Errorf("entry = %+v", entry)
+	}
+	if string(entry.FailedURLs) != "[]" || string(entry.PartialResults) != "[]" {
+		t.Errorf("lists = %s, %s, want []", entry.FailedURLs, entry.PartialResults)
+	}
+	var task model.ImageProcessingTask
+	if err := json.Unmarshal(entry.Task, &task); err != nil || task.ImageURLs[0] != "https://example.com/a.jpg" {
+		t.Errorf("task = %s", entry.Task)
+	}
+
+	// The id derives from the position only
+	again, _ := backfillEntry(kafka.DLQRecord{Partition: 1, Offset: 42, Message: message, ReplayedAt: &replayedAt})
+	other, _ := backfillEntry(kafka.DLQRecord{Partition: 1, Offset: 43, Message: message})
+	if again.ID != entry.ID || other.ID == entry.ID {
+		t.Errorf("ids = %s, %s, %s", entry.ID, again.ID, other.ID)
+	}
+	if again.Status != model.DLQStatusReplayed || !again.ResolvedAt.Equal(replayedAt) {
+		t.Errorf("replayed entry = %+v", again)
+	}
+
+	invalid := message
+	invalid.OriginalTask.ProductID = "not-a-uuid"
+	for name, record := range map[string]kafka.DLQRecord{
+		"undecodable":        {Invalid: true},
+		"invalid product id": {Message: invalid},
+	} {
+		if _, ok := backfillEntry(record); ok {
+			t.Errorf("%s: message accepted", name)
+		}
+	}
+}
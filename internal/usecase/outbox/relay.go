@@ -0,0 +1,132 @@
+// internal/usecase/outbox/relay.go
+
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/kafka"
+	"go.uber.org/zap"
+)
+
+const (
+	relayBatchSize  = 100
+	relayLease      = 30 * time.Second
+	cleanupInterval = time.Hour
+	baseRetryDelay  = time.Second
+	maxRetryDelay   = 5 * time.Minute
+)
+
+// Relay publishes messages from the transactional outbox to Kafka. Messages
+// are retried with exponential backoff until delivered, so none are lost while
+// Kafka is unavailable; delivered messages are deleted after the retention.
+type Relay struct {
+	repo         repository.OutboxRepository
+	publisher    *kafka.Publisher
+	pollInterval time.Duration
+	retention    time.Duration
+	logger       *zap.Logger
+}
+
+func NewRelay(repo repository.OutboxRepository, publisher *kafka.Publisher, pollInterval, retention time.Duration, logger *zap.Logger) *Relay {
+	return &Relay{
+		repo:         repo,
+		publisher:    publisher,
+		pollInterval: pollInterval,
+		retention:    retention,
+		logger:       logger,
+	}
+}
+
+// Start relays pending messages on every poll interval until the context is cancelled.
+func (r *Relay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	lastCleanup := time.Time{}
+
+	for {
+		// Keep going while full batches come back, so a backlog drains quickly
+		for {
+			relayed, err := r.RelayPending(ctx)
+			if err != nil {
+				r.logger.Error("Failed to relay outbox messages", zap.Error(err))
+			}
+			if err != nil || relayed < relayBatchSize {
+				break
+			}
+		}
+
+		if time.Since(lastCleanup) >= cleanupInterval {
+			r.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Context cancelled, stopping outbox relay")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes one batch of due messages and returns how many were claimed.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	messages, err := r.repo.ClaimPending(ctx, r.publisher.Topic(), relayBatchSize, relayLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+
+	for _, message := range messages {
+		if err := r.publisher.PublishWithKey(ctx, message.AggregateID.String(), message.Payload); err != nil {
+			nextAttemptAt := time.Now().Add(retryDelay(message.Attempts))
+			r.logger.Warn("Failed to publish outbox message, will retry",
+				zap.Error(err),
+				zap.Int64("outbox_id", message.ID),
+				zap.String("aggregate_id", message.AggregateID.String()),
+				zap.Int("attempts", message.Attempts+1),
+				zap.Time("next_attempt_at", nextAttemptAt))
+			if err := r.repo.MarkFailed(ctx, message.ID, err.Error(), nextAttemptAt); err != nil {
+				r.logger.Error("Failed to record outbox publish failure",
+					zap.Error(err),
+					zap.Int64("outbox_id", message.ID))
+			}
+			continue
+		}
+
+		if err := r.repo.MarkDelivered(ctx, message.ID); err != nil {
+			// The lease expires and the message is published again; consumers tolerate duplicates
+			r.logger.Error("Failed to mark outbox message delivered",
+				zap.Error(err),
+				zap.Int64("outbox_id", message.ID))
+		}
+	}
+
+	return len(messages), nil
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	deleted, err := r.repo.DeleteDelivered(ctx, time.Now().Add(-r.retention))
+	if err != nil {
+		r.logger.Error("Failed to delete delivered outbox messages", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		r.logger.Info("Deleted delivered outbox messages", zap.Int64("count", deleted))
+	}
+}
+
+// retryDelay doubles the delay with every failed attempt, up to maxRetryDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 0; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
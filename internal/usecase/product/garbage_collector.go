@@ -0,0 +1,109 @@
+// internal/usecase/product/garbage_collector.go
+
+package product
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+	"go.uber.org/zap"
+)
+
+// gcPrefixes are the prefixes of the objects the garbage collector owns:
+// processed images, and uploads that may never be used by a product
+var gcPrefixes = []string{model.ProductObjectPrefix, model.UploadKeyPrefix}
+
+// GarbageCollector deletes the objects no table refers to anymore: images
+// of replaced sources whose deletion failed, objects of failed partial
+// runs and unused uploads. Objects younger than the grace period are kept,
+// as their reference may not be committed yet.
+type GarbageCollector struct {
+	refs   repository.ObjectReferenceRepository
+	store  storage.ObjectStore
+	grace  time.Duration
+	logger *zap.Logger
+}
+
+func NewGarbageCollector(refs repository.ObjectReferenceRepository, store storage.ObjectStore, grace time.Duration, logger *zap.Logger) *GarbageCollector {
+	return &GarbageCollector{
+		refs:   refs,
+		store:  store,
+		grace:  grace,
+		logger: logger,
+	}
+}
+
+// GCReport describes a garbage collection run.
+type GCReport struct {
+	Scanned int
+	// Recent counts the objects younger than the grace period, not checked
+	Recent int
+	// Orphaned lists the unreferenced objects older than the grace period,
+	// deleted unless the run was a dry run
+	Orphaned     []storage.ObjectInfo
+	OrphanedSize int64
+	Deleted      int
+	Failed       int
+}
+
+// Collect deletes the unreferenced objects older than the grace period, or
+// with dryRun only reports them. Objects are listed before references are
+// read, so an object referenced when it was listed is never deleted.
+func (g *GarbageCollector) Collect(ctx context.Context, dryRun bool) (*GCReport, error) {
+	cutoff := time.Now().Add(-g.grace)
+	report := &GCReport{}
+
+	var candidates []storage.ObjectInfo
+	for _, prefix := range gcPrefixes {
+		err := g.store.List(ctx, prefix, func(info storage.ObjectInfo) error {
+			report.Scanned++
+			if !info.LastModified.Before(cutoff) {
+				report.Recent++
+				return nil
+			}
+			candidates = append(candidates, info)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+	}
+
+	refs, err := g.refs.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list object references: %w", err)
+	}
+	referenced := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		// References outside the store refer to none of its objects
+		if key, ok := storage.ObjectKey(g.store, ref); ok {
+			referenced[key] = true
+		}
+	}
+
+	for _, info := range candidates {
+		if referenced[info.Key] {
+			continue
+		}
+		report.Orphaned = append(report.Orphaned, info)
+		report.OrphanedSize += info.Size
+		if dryRun {
+			continue
+		}
+
+		if err := g.store.Delete(ctx, info.Key); err != nil {
+			g.logger.Warn("Failed to delete orphaned object",
+				zap.String("key", info.Key),
+				zap.Error(err))
+			report.Failed++
+			continue
+		}
+		report.Deleted++
+	}
+
+	return report, nil
+}
@@ -0,0 +1,154 @@
+// internal/usecase/product/garbage_collector_test.go
+
+package product
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/objectstore"
+	"go.uber.org/zap"
+)
+
+const gcBaseURL = "http://localhost:8080/files"
+
+// fakeRefs returns fixed references.
+type fakeRefs struct {
+	refs []string
+	err  error
+}
+
+func (r fakeRefs) List(ctx context.Context) ([]string, error) { return r.refs, r.err }
+
+// failingDeletes is a memory store whose deletes of some keys fail.
+type failingDeletes struct {
+	*objectstore.MemoryStore
+	keys []string
+}
+
+func (s failingDeletes) Delete(ctx context.Context, key string) error {
+	if slices.Contains(s.keys, key) {
+		return errors.New("delete failed")
+	}
+	return s.MemoryStore.Delete(ctx, key)
+}
+
+func gcStore(t *testing.T) *objectstore.MemoryStore {
+	t.Helper()
+	store := objectstore.NewMemoryStore(gcBaseURL, nil)
+	for _, key := range []string{
+		"products/p1/a-card.jpg",
+		"products/p1/a-zoom.jpg",
+		"products/p2/b-card.webp",
+		"products/p3/c-card.jpg",
+		"uploads/u1.png",
+		"uploads/u2.png",
+		"other/kept.jpg",
+	} {
+		if err := store.Put(context.Background(), key, strings.NewReader(key), storage.ContentType(key)); err != nil {
+			t.Fatalf("Put(%q) error = %v", key, err)
+		}
+	}
+	return store
+}
+
+func orphanedKeys(report *GCReport) []string {
+	var keys []string
+	for _, info := range report.Orphaned {
+		keys = append(keys, info.Key)
+	}
+	return keys
+}
+
+func TestCollect(t *testing.T) {
+	refs := []string{
+		// A key, a URL of the store and a URL outside it
+		"products/p1/a-card.jpg",
+		gcBaseURL + "/products/p2/b-card.webp",
+		"https://cdn.example.com/products/p3/c-card.jpg",
+		"uploads/u1.png",
+	}
+	wantOrphaned := []string{"products/p1/a-zoom.jpg", "products/p3/c-card.jpg", "uploads/u2.png"}
+
+	tests := []struct {
+		name        string
+		grace       time.Duration
+		dryRun      bool
+		failDeletes []string
+		wantOrphans []string
+		wantRecent  int
+		wantDeleted int
+		wantFailed  int
+	}{
+		{"delete", 0, false, nil, wantOrphaned, 0, 3, 0},
+		{"dry run", 0, true, nil, wantOrphaned, 0, 0, 0},
+		{"within the grace period", time.Hour, false, nil, nil, 6, 0, 0},
+		{"failed delete", 0, false, []string{"uploads/u2.png"}, wantOrphaned, 0, 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := gcStore(t)
+			store := failingDeletes{MemoryStore: memory, keys: tt.failDeletes}
+			gc := NewGarbageCollector(fakeRefs{refs: refs}, store, tt.grace, zap.NewNop())
+
+			report, err := gc.Collect(context.Background(), tt.dryRun)
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if report.Scanned != 6 {
+				t.Errorf("Scanned = %d, want 6", report.Scanned)
+			}
+			if report.Recent != tt.wantRecent || report.Deleted != tt.wantDeleted || report.Failed != tt.wantFailed {
+				t.Errorf("Recent, Deleted, Failed = %d, %d, %d, want %d, %d, %d",
+					report.Recent, report.Deleted, report.Failed, tt.wantRecent, tt.wantDeleted, tt.wantFailed)
+			}
+			if got := orphanedKeys(report); !slices.Equal(got, tt.wantOrphans) {
+				t.Errorf("Orphaned = %v, want %v", got, tt.wantOrphans)
+			}
+			var size int64
+			for _, key := range tt.wantOrphans {
+				size += int64(len(key))
+			}
+			if report.OrphanedSize != size {
+				t.Errorf("OrphanedSize = %d, want %d", report.OrphanedSize, size)
+			}
+
+			// Referenced objects, those outside the collected prefixes and
+			// those not deleted remain
+			for _, key := range []string{"products/p1/a-card.jpg", "products/p2/b-card.webp", "uploads/u1.png", "other/kept.jpg"} {
+				if exists, _ := memory.Exists(context.Background(), key); !exists {
+					t.Errorf("%s was deleted", key)
+				}
+			}
+			for _, key := range tt.wantOrphans {
+				deleted := !tt.dryRun && !slices.Contains(tt.failDeletes, key)
+				if exists, _ := memory.Exists(context.Background(), key); exists == deleted {
+					t.Errorf("%s exists = %v, want %v", key, exists, !deleted)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectFailsWithoutReferences(t *testing.T) {
+	store := gcStore(t)
+	gc := NewGarbageCollector(fakeRefs{err: errors.New("database down")}, store, 0, zap.NewNop())
+
+	if _, err := gc.Collect(context.Background(), false); err == nil {
+		t.Fatal("Collect() succeeded without references")
+	}
+	// Nothing is deleted when references cannot be read
+	var count int
+	store.List(context.Background(), "", func(storage.ObjectInfo) error {
+		count++
+		return nil
+	})
+	if count != 7 {
+		t.Errorf("%d objects remain, want 7", count)
+	}
+}
@@ -0,0 +1,147 @@
+// internal/usecase/product/trash_purger.go
+
+package product
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/repository"
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+	"github.com/iSparshP/product-management-system/pkg/utils"
+	"go.uber.org/zap"
+)
+
+const purgeBatchSize = 100
+
+// TrashPurger permanently removes products that have been in the trash
+// longer than the retention window, along with their compressed images.
+type TrashPurger struct {
+	repo            repository.ProductRepository
+	processedImages repository.ProcessedImageRepository
+	store           storage.ObjectStore
+	retention       time.Duration
+	interval        time.Duration
+	logger          *zap.Logger
+}
+
+func NewTrashPurger(repo repository.ProductRepository, processedImages repository.ProcessedImageRepository, store storage.ObjectStore, retention, interval time.Duration, logger *zap.Logger) *TrashPurger {
+	return &TrashPurger{
+		repo:            repo,
+		processedImages: processedImages,
+		store:           store,
+		retention:       retention,
+		interval:        interval,
+		logger:          logger,
+	}
+}
+
+// Start runs the purge on every interval until the context is cancelled.
+func (p *TrashPurger) Start(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := p.Purge(ctx); err != nil {
+			p.logger.Error("Failed to purge trashed products", zap.Error(err))
+		} else if purged > 0 {
+			p.logger.Info("Purged trashed products", zap.Int("count", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Context cancelled, stopping trash purger")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes every product trashed before the retention cutoff and
+// returns how many were removed.
+func (p *TrashPurger) Purge(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-p.retention)
+	purged := 0
+
+	for {
+		products, err := p.repo.GetTrashedBefore(ctx, cutoff, purgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to get trashed products: %w", err)
+		}
+
+		removed := 0
+		for i := range products {
+			if err := p.purgeProduct(ctx, &products[i]); err != nil {
+				p.logger.Error("Failed to purge product",
+					zap.Error(err),
+					zap.String("product_id", products[i].ID.String()))
+				continue
+			}
+			removed++
+		}
+		purged += removed
+
+		// Stop when the backlog is drained or every remaining row keeps failing
+		if len(products) < purgeBatchSize || removed == 0 {
+			return purged, nil
+		}
+	}
+}
+
+// purgeProduct deletes the images stored for the product alone first, so
+// that a failure leaves the product in the trash to be retried on the next
+// run. Its processed images are then released; the objects of those no other
+// product uses are deleted, or left to the garbage collector on failure.
+func (p *TrashPurger) purgeProduct(ctx context.Context, product *model.Product) error {
+	for _, ref := range imageRefs(product) {
+		key, ok := storage.ObjectKey(p.store, ref)
+		if !ok {
+			p.logger.Warn("Skipping compressed image outside the object store",
+				zap.String("product_id", product.ID.String()),
+				zap.String("url", ref))
+			continue
+		}
+		// Processed images may be shared; they go once released
+		if model.IsProcessedImageKey(key) {
+			continue
+		}
+		if err := p.store.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete compressed image: %w", err)
+		}
+	}
+
+	released, err := p.processedImages.Release(ctx, product.ID.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to release processed images: %w", err)
+	}
+	if err := storage.DeleteProcessedImages(ctx, p.store, released); err != nil {
+		p.logger.Warn("Failed to delete released image objects",
+			zap.String("product_id", product.ID.String()),
+			zap.Error(err))
+	}
+
+	return p.repo.Purge(ctx, product.ID.String())
+}
+
+// imageRefs lists the stored references to the compressed images and
+// variants of a product, each once.
+func imageRefs(product *model.Product) []string {
+	var refs []string
+	for _, renditions := range utils.JSONToRenditions(product.CompressedProductImages) {
+		for _, ref := range renditions {
+			refs = append(refs, ref)
+		}
+	}
+	if variants, err := product.Variants(); err == nil {
+		for _, imageVariants := range variants {
+			for _, variant := range imageVariants {
+				refs = append(refs, variant.Ref())
+			}
+		}
+	}
+	slices.Sort(refs)
+	return slices.Compact(refs)
+}
@@ -0,0 +1,150 @@
+// internal/usecase/product/upload.go
+
+package product
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"go.uber.org/zap"
+)
+
+// sniffLen is how much of an upload http.DetectContentType looks at
+const sniffLen = 512
+
+// UploadConfig bounds the images sellers upload.
+type UploadConfig struct {
+	// MaxBytes bounds the images uploaded through the API
+	MaxBytes int64
+	// MaxPresignedBytes bounds the images uploaded to presigned URLs
+	MaxPresignedBytes int64
+	// URLExpiry is how long presigned URLs stay valid
+	URLExpiry time.Duration
+}
+
+func (u *usecase) PresignUpload(ctx context.Context, input model.PresignUploadInput) (*model.PresignedUpload, error) {
+	if input.Size > u.uploads.MaxPresignedBytes {
+		return nil, fmt.Errorf("%w: images are limited to %d bytes", ErrInvalidInput, u.uploads.MaxPresignedBytes)
+	}
+	userID, err := uuid.Parse(input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user_id format", ErrInvalidInput)
+	}
+	key, ok := model.NewUploadKey(userID, input.ContentType)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported content type %q", ErrInvalidInput, input.ContentType)
+	}
+
+	expiresAt := time.Now().Add(u.uploads.URLExpiry)
+	uploadURL, err := u.store.PresignedPutURL(ctx, key, input.ContentType, input.Size, u.uploads.URLExpiry)
+	if err != nil {
+		u.logger.Error("Failed to presign upload", zap.String("key", key), zap.Error(err))
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	return &model.PresignedUpload{
+		Key:    key,
+		Method: http.MethodPut,
+		URL:    uploadURL,
+		Headers: map[string]string{
+			"Content-Type": input.ContentType,
+		},
+		ExpiresAt: expiresAt.UTC(),
+	}, nil
+}
+
+// errUploadTooLarge stops a store reading an upload past the size limit
+var errUploadTooLarge = errors.New("upload too large")
+
+// uploadReader counts the bytes of an upload, failing once they exceed max.
+type uploadReader struct {
+	r    io.Reader
+	size int64
+	max  int64
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.size += int64(n)
+	if r.size > r.max {
+		return n, errUploadTooLarge
+	}
+	return n, err
+}
+
+// UploadImage streams the image to the store under a fresh upload key of the
+// user. Its type is sniffed from the first bytes, whatever the client claims;
+// an image exceeding the size limit fails the store write, which keeps nothing.
+func (u *usecase) UploadImage(ctx context.Context, userID string, body io.Reader) (*model.Upload, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user_id format", ErrInvalidInput)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	key, ok := model.NewUploadKey(userUUID, contentType)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported content type %q", ErrInvalidInput, contentType)
+	}
+
+	upload := &uploadReader{r: io.MultiReader(bytes.NewReader(head), body), max: u.uploads.MaxBytes}
+	if err := u.store.Put(ctx, key, upload, contentType); err != nil {
+		if errors.Is(err, errUploadTooLarge) {
+			return nil, fmt.Errorf("%w: uploads are limited to %d bytes", ErrInvalidInput, u.uploads.MaxBytes)
+		}
+		u.logger.Error("Failed to store upload", zap.String("key", key), zap.Error(err))
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	uploadURL, err := u.urls.ObjectURL(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve upload URL: %w", err)
+	}
+
+	return &model.Upload{
+		Key:         key,
+		URL:         uploadURL,
+		ContentType: contentType,
+		Size:        upload.size,
+	}, nil
+}
+
+// checkUploads verifies that the uploaded images of a product exist, so
+// products do not refer to uploads that never completed, and that those not
+// among its previous images were uploaded by its owner, so sellers cannot
+// list each other's uploads.
+func (u *usecase) checkUploads(ctx context.Context, userID string, images, previous []string) error {
+	for _, image := range images {
+		if !model.IsUploadKey(image) {
+			continue
+		}
+		if !slices.Contains(previous, image) {
+			if owner, ok := model.UploadOwner(image); !ok || owner != userID {
+				return fmt.Errorf("%w: upload %s not found", ErrInvalidInput, image)
+			}
+		}
+		exists, err := u.store.Exists(ctx, image)
+		if err != nil {
+			return fmt.Errorf("failed to check upload: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: upload %s not found", ErrInvalidInput, image)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// internal/usecase/product/upload_test.go
+
+package product
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+	"github.com/iSparshP/product-management-system/internal/infrastructure/objectstore"
+	"go.uber.org/zap"
+)
+
+const uploaderID = "6f1d2e3b-0c4a-4b55-9a57-4f1c2a9e6b1d"
+
+// keyURLs resolves references to themselves.
+type keyURLs struct{}
+
+func (keyURLs) ObjectURL(ctx context.Context, ref string) (string, error) { return ref, nil }
+
+func testPNG(t *testing.T, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, size, size))); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadImage(t *testing.T) {
+	small := testPNG(t, 4)
+
+	tests := []struct {
+		name     string
+		userID   string
+		body     []byte
+		maxBytes int64
+		wantErr  error
+	}{
+		{"image", uploaderID, small, 1 << 20, nil},
+		{"exactly the limit", uploaderID, small, int64(len(small)), nil},
+		{"over the limit", uploaderID, small, int64(len(small)) - 1, ErrInvalidInput},
+		{"shorter than the sniffed bytes", uploaderID, small[:40], 1 << 20, nil},
+		{"not an image", uploaderID, []byte(strings.Repeat("text ", 200)), 1 << 20, ErrInvalidInput},
+		{"empty", uploaderID, nil, 1 << 20, ErrInvalidInput},
+		{"invalid user", "seller-1", small, 1 << 20, ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := objectstore.NewMemoryStore("http://localhost:8080/files", nil)
+			u := &usecase{
+				store:   store,
+				urls:    keyURLs{},
+				uploads: UploadConfig{MaxBytes: tt.maxBytes},
+				logger:  zap.NewNop(),
+			}
+
+			upload, err := u.UploadImage(context.Background(), tt.userID, bytes.NewReader(tt.body))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UploadImage() error = %v, want %v", err, tt.wantErr)
+				}
+				// Nothing is kept of a refused upload
+				store.List(context.Background(), "", func(info storage.ObjectInfo) error {
+					t.Errorf("object %s stored for a refused upload", info.Key)
+					return nil
+				})
+				return
+			}
+			if err != nil {
+				t.Fatalf("UploadImage() error = %v", err)
+			}
+
+			if owner, _ := model.UploadOwner(upload.Key); owner != tt.userID {
+				t.Errorf("UploadImage() key %s is not owned by %s", upload.Key, tt.userID)
+			}
+			if upload.ContentType != "image/png" || !strings.HasSuffix(upload.Key, ".png") || upload.URL != upload.Key {
+				t.Errorf("UploadImage() = %+v", upload)
+			}
+			if upload.Size != int64(len(tt.body)) {
+				t.Errorf("UploadImage() size = %d, want %d", upload.Size, len(tt.body))
+			}
+			body, _, err := store.Get(context.Background(), upload.Key)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer body.Close()
+			if data, _ := io.ReadAll(body); !bytes.Equal(data, tt.body) {
+				t.Error("stored upload differs from the body")
+			}
+		})
+	}
+}
+
+func TestImageSources(t *testing.T) {
+	const key = "uploads/0b5e4a3c-6e2f-4f58-9d4c-3a1d0c2b7e11.png"
+	u := &usecase{store: objectstore.NewMemoryStore("http://localhost:8080/files", nil)}
+
+	images := []string{
+		key,
+		"http://localhost:8080/files/" + key + "?expires=1&signature=abc",
+		"http://localhost:8080/files/products/p1/a-card.jpg",
+		"https://example.com/" + key,
+	}
+	want := []string{key, key, images[2], images[3]}
+	got := u.imageSources(images)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("imageSources()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCheckUploads(t *testing.T) {
+	const (
+		owned  = "uploads/" + uploaderID + "/0b5e4a3c-6e2f-4f58-9d4c-3a1d0c2b7e11.png"
+		other  = "uploads/9a57c1d2-3b4a-4f1c-8e6b-1d0c2b7e116f/0b5e4a3c-6e2f-4f58-9d4c-3a1d0c2b7e11.png"
+		legacy = "uploads/0b5e4a3c-6e2f-4f58-9d4c-3a1d0c2b7e11.png"
+		absent = "uploads/" + uploaderID + "/4f1c2a9e-6b1d-4c55-9a57-0f6c1d2e3b4a.png"
+	)
+	store := objectstore.NewMemoryStore("http://localhost:8080/files", nil)
+	for _, key := range []string{owned, other, legacy} {
+		if err := store.Put(context.Background(), key, strings.NewReader(key), "image/png"); err != nil {
+			t.Fatalf("Put(%q) error = %v", key, err)
+		}
+	}
+	u := &usecase{store: store}
+
+	tests := []struct {
+		name     string
+		images   []string
+		previous []string
+		wantErr  error
+	}{
+		{"own upload", []string{"https://example.com/a.jpg", owned}, nil, nil},
+		{"upload of another user", []string{other}, nil, ErrInvalidInput},
+		{"upload without owner", []string{legacy}, nil, ErrInvalidInput},
+		{"previous images are kept", []string{owned, legacy, other}, []string{legacy, other}, nil},
+		{"missing upload", []string{absent}, nil, ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := u.checkUploads(context.Background(), uploaderID, tt.images, tt.previous); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkUploads() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// internal/usecase/product/urls.go
+
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/iSparshP/product-management-system/internal/domain/model"
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
+	"github.com/iSparshP/product-management-system/pkg/utils"
+)
+
+// withImageURLs resolves the image URLs of a product about to be returned.
+func (u *usecase) withImageURLs(ctx context.Context, product *model.Product) (*model.Product, error) {
+	if err := u.resolveImageURLs(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// withPageImageURLs resolves the image URLs of a page of products.
+func (u *usecase) withPageImageURLs(ctx context.Context, page *model.ProductPage) (*model.ProductPage, error) {
+	for i := range page.Data {
+		if err := u.resolveImageURLs(ctx, &page.Data[i]); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// resolveImageURLs replaces the object references stored in the images,
+// compressed images and variants of a product by the URLs clients fetch them
+// from. Source images linked from elsewhere are returned as they are.
+func (u *usecase) resolveImageURLs(ctx context.Context, product *model.Product) error {
+	if len(product.ProductImages) > 0 {
+		images := utils.JSONToStringSlice(product.ProductImages)
+		for i, image := range images {
+			if !model.IsUploadKey(image) {
+				continue
+			}
+			objectURL, err := u.urls.ObjectURL(ctx, image)
+			if err != nil {
+				return fmt.Errorf("failed to resolve image URL: %w", err)
+			}
+			images[i] = objectURL
+		}
+		product.ProductImages = utils.StringSliceToJSON(images)
+	}
+
+	if len(product.CompressedProductImages) > 0 {
+		renditions := utils.JSONToRenditions(product.CompressedProductImages)
+		for _, byName := range renditions {
+			if err := u.resolveRenditions(ctx, byName); err != nil {
+				return err
+			}
+		}
+		product.CompressedProductImages = utils.RenditionsToJSON(renditions)
+	}
+
+	if len(product.ImageVariants) > 0 {
+		variants, err := product.Variants()
+		if err != nil {
+			return err
+		}
+		for _, imageVariants := range variants {
+			if err := u.resolveVariants(ctx, imageVariants); err != nil {
+				return err
+			}
+		}
+		if product.ImageVariants, err = json.Marshal(variants); err != nil {
+			return fmt.Errorf("failed to marshal image variants: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveStatusURLs resolves the renditions and variants of an image status
+// like resolveImageURLs.
+func (u *usecase) resolveStatusURLs(ctx context.Context, status *model.ProductImageStatus) error {
+	var renditions map[string]string
+	if err := json.Unmarshal(status.Renditions, &renditions); err == nil && len(renditions) > 0 {
+		if err := u.resolveRenditions(ctx, renditions); err != nil {
+			return err
+		}
+		status.Renditions = utils.StringMapToJSON(renditions)
+	}
+
+	var variants []model.ImageVariant
+	if err := json.Unmarshal(status.Variants, &variants); err == nil && len(variants) > 0 {
+		if err := u.resolveVariants(ctx, variants); err != nil {
+			return err
+		}
+		variantsJSON, err := json.Marshal(variants)
+		if err != nil {
+			return fmt.Errorf("failed to marshal image variants: %w", err)
+		}
+		status.Variants = variantsJSON
+	}
+	return nil
+}
+
+func (u *usecase) resolveRenditions(ctx context.Context, renditions map[string]string) error {
+	for name, ref := range renditions {
+		objectURL, err := u.urls.ObjectURL(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("failed to resolve image URL: %w", err)
+		}
+		renditions[name] = objectURL
+	}
+	return nil
+}
+
+func (u *usecase) resolveVariants(ctx context.Context, variants []model.ImageVariant) error {
+	for i := range variants {
+		objectURL, err := u.urls.ObjectURL(ctx, variants[i].Ref())
+		if err != nil {
+			return fmt.Errorf("failed to resolve image URL: %w", err)
+		}
+		variants[i].URL = objectURL
+	}
+	return nil
+}
+
+// imageSources turns the URLs of uploads clients send back, as returned by
+// resolveImageURLs, into their keys, so that products keep storing keys.
+func (u *usecase) imageSources(images []string) []string {
+	sources := make([]string, len(images))
+	for i, image := range images {
+		sources[i] = image
+		if key, ok := storage.ObjectKey(u.store, image); ok && model.IsUploadKey(key) {
+			sources[i] = key
+		}
+	}
+	return sources
+}
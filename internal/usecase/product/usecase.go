@@ -3,39 +3,80 @@
 package product
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
 	"time"
 
+	"github.com/go-playground/validator/v10"
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/iSparshP/product-management-system/internal/domain/model"
 	"github.com/iSparshP/product-management-system/internal/domain/repository"
-	"github.com/iSparshP/product-management-system/internal/infrastructure/kafka"
+	"github.com/iSparshP/product-management-system/internal/domain/storage"
 	"github.com/iSparshP/product-management-system/internal/infrastructure/redis"
 	"github.com/iSparshP/product-management-system/pkg/utils"
 	"go.uber.org/zap"
+	"gorm.io/datatypes"
 )
 
 type Usecase interface {
 	CreateProduct(ctx context.Context, input model.CreateProductInput) (*model.Product, error)
 	GetProductByID(ctx context.Context, id string) (*model.Product, error)
-	GetProducts(ctx context.Context, userID string, filters map[string]interface{}) ([]model.Product, error)
+	GetProducts(ctx context.Context, userID string, filter model.ProductFilter, page model.PageRequest) (*model.ProductPage, error)
+	UpdateProduct(ctx context.Context, id string, input model.UpdateProductInput, precondition model.VersionPrecondition) (*model.Product, error)
+	PatchProduct(ctx context.Context, id string, patch []byte, precondition model.VersionPrecondition) (*model.Product, error)
+	DeleteProduct(ctx context.Context, id string, precondition model.VersionPrecondition) error
+	GetTrash(ctx context.Context, userID string) ([]model.Product, error)
+	RestoreProduct(ctx context.Context, id string) (*model.Product, error)
+	GetImageStatus(ctx context.Context, id string) (*model.ProductImageStatusReport, error)
+	// PresignUpload returns a URL the client uploads an image to directly.
+	PresignUpload(ctx context.Context, input model.PresignUploadInput) (*model.PresignedUpload, error)
+	// UploadImage stores an image sent through the API.
+	UploadImage(ctx context.Context, userID string, body io.Reader) (*model.Upload, error)
 }
 
+// ErrInvalidInput is returned when a request payload fails validation.
+var ErrInvalidInput = errors.New("invalid input")
+
+// Product listings change whenever the image processor finishes, which does
+// not bump the list generation, so listings are only cached briefly.
+const productListCacheTTL = time.Minute
+
 type usecase struct {
-	repo        repository.ProductRepository
-	kafkaPub    *kafka.Publisher
-	redisClient *redis.Client
-	logger      *zap.Logger
+	repo            repository.ProductRepository
+	imageStatusRepo repository.ImageStatusRepository
+	store           storage.ObjectStore
+	urls            storage.URLResolver
+	uploads         UploadConfig
+	redisClient     *redis.Client
+	validate        *validator.Validate
+	logger          *zap.Logger
 }
 
-func NewProductUsecase(repo repository.ProductRepository, kafkaPub *kafka.Publisher, redisClient *redis.Client, logger *zap.Logger) Usecase {
+func NewProductUsecase(repo repository.ProductRepository, imageStatusRepo repository.ImageStatusRepository, store storage.ObjectStore, urls storage.URLResolver, uploads UploadConfig, redisClient *redis.Client, logger *zap.Logger) Usecase {
+	// Validate merged patches with the same rules Gin applies to request bodies
+	validate := validator.New()
+	validate.SetTagName("binding")
+	if err := model.RegisterValidations(validate); err != nil {
+		logger.Fatal("Failed to register validations", zap.Error(err))
+	}
+
 	return &usecase{
-		repo:        repo,
-		kafkaPub:    kafkaPub,
-		redisClient: redisClient,
-		logger:      logger,
+		repo:            repo,
+		imageStatusRepo: imageStatusRepo,
+		store:           store,
+		urls:            urls,
+		uploads:         uploads,
+		redisClient:     redisClient,
+		validate:        validate,
+		logger:          logger,
 	}
 }
 
@@ -46,21 +87,34 @@ func (u *usecase) CreateProduct(ctx context.Context, input model.CreateProductIn
 		u.logger.Error("Invalid user ID format", zap.String("user_id", input.UserID), zap.Error(err))
 		return nil, fmt.Errorf("invalid user_id format: %w", err)
 	}
+	input.ProductImages = u.imageSources(input.ProductImages)
+	if err := u.checkUploads(ctx, userUUID.String(), input.ProductImages, nil); err != nil {
+		return nil, err
+	}
 
 	// Create Product
 	product := &model.Product{
-		ID:                 uuid.New(),
-		UserID:             userUUID,
-		ProductName:        input.ProductName,
-		ProductDescription: input.ProductDescription,
-		ProductImages:      utils.StringSliceToJSON(input.ProductImages),
-		ProductPrice:       input.ProductPrice,
-		CreatedAt:          time.Now(),
-		UpdatedAt:          time.Now(),
+		ID:                    uuid.New(),
+		UserID:                userUUID,
+		ProductName:           input.ProductName,
+		ProductDescription:    input.ProductDescription,
+		ProductImages:         utils.StringSliceToJSON(input.ProductImages),
+		ProductPrice:          input.ProductPrice,
+		ImageProcessingStatus: model.ImageProcessingPending,
+		Version:               1,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+
+	// The image processing task is stored in the outbox in the same
+	// transaction and published to Kafka by the outbox relay
+	task, err := newImageProcessingMessage(product.ID, input.ProductImages)
+	if err != nil {
+		return nil, err
 	}
 
 	// Save to Database
-	if err := u.repo.Create(ctx, product); err != nil {
+	if err := u.repo.Create(ctx, product, task); err != nil {
 		u.logger.Error("Failed to create product in database",
 			zap.Error(err),
 			zap.String("product_id", product.ID.String()),
@@ -68,38 +122,16 @@ func (u *usecase) CreateProduct(ctx context.Context, input model.CreateProductIn
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
 
-	// Publish to Kafka for image processing
-	task := model.ImageProcessingTask{
-		ProductID: product.ID.String(),
-		ImageURLs: input.ProductImages,
-	}
-
-	taskData, err := json.Marshal(task)
-	if err != nil {
-		u.logger.Error("Failed to marshal image processing task",
-			zap.Error(err),
-			zap.String("product_id", product.ID.String()))
-		// Continue execution as image processing is not critical for product creation
-	} else {
-		// Only attempt to publish if marshaling succeeded
-		if err := u.kafkaPub.Publish(ctx, taskData); err != nil {
-			u.logger.Error("Failed to publish image processing task",
-				zap.Error(err),
-				zap.String("product_id", product.ID.String()))
-			// Consider implementing retry logic here
-			// For now, we'll continue as image processing is not critical
-		}
-	}
-
 	// After successful creation, invalidate any existing cache
 	u.invalidateProductCache(ctx, product.ID.String())
+	u.invalidateProductListCache(ctx, userUUID.String())
 
 	return product, nil
 }
 
 func (u *usecase) GetProductByID(ctx context.Context, id string) (*model.Product, error) {
 	// Check Redis Cache first
-	cacheKey := fmt.Sprintf("product:%s", id)
+	cacheKey := model.ProductCacheKey(id)
 	cachedProduct, err := u.redisClient.Get(ctx, cacheKey)
 	if err == nil {
 		var product model.Product
@@ -107,7 +139,7 @@ func (u *usecase) GetProductByID(ctx context.Context, id string) (*model.Product
 			u.logger.Debug("Cache hit for product",
 				zap.String("id", id),
 				zap.String("cache_key", cacheKey))
-			return &product, nil
+			return u.withImageURLs(ctx, &product)
 		}
 		u.logger.Warn("Failed to unmarshal cached product",
 			zap.Error(err),
@@ -138,25 +170,286 @@ func (u *usecase) GetProductByID(ctx context.Context, id string) (*model.Product
 		}
 	}
 
-	return product, nil
+	return u.withImageURLs(ctx, product)
 }
 
-func (u *usecase) GetProducts(ctx context.Context, userID string, filters map[string]interface{}) ([]model.Product, error) {
-	products, err := u.repo.GetAll(ctx, userID, filters)
+func (u *usecase) GetProducts(ctx context.Context, userID string, filter model.ProductFilter, page model.PageRequest) (*model.ProductPage, error) {
+	// Check Redis Cache first
+	cacheKey, err := u.productListCacheKey(ctx, userID, filter, page)
+	if err == nil {
+		if cachedPage, err := u.redisClient.Get(ctx, cacheKey); err == nil {
+			var products model.ProductPage
+			if err := json.Unmarshal([]byte(cachedPage), &products); err == nil {
+				u.logger.Debug("Cache hit for product list",
+					zap.String("user_id", userID),
+					zap.String("cache_key", cacheKey))
+				return u.withPageImageURLs(ctx, &products)
+			}
+		}
+	} else {
+		u.logger.Warn("Failed to build product list cache key",
+			zap.Error(err),
+			zap.String("user_id", userID))
+	}
+
+	// Cache miss - fetch from database
+	products, err := u.repo.GetAll(ctx, userID, filter, page)
 	if err != nil {
 		u.logger.Error("Failed to get products",
 			zap.Error(err),
 			zap.String("user_id", userID),
-			zap.Any("filters", filters))
+			zap.Any("filter", filter),
+			zap.Any("page", page))
 		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
 
+	if cacheKey != "" {
+		if pageData, err := json.Marshal(products); err == nil {
+			if err := u.redisClient.Set(ctx, cacheKey, pageData, productListCacheTTL); err != nil {
+				u.logger.Warn("Failed to cache product list",
+					zap.Error(err),
+					zap.String("user_id", userID),
+					zap.String("cache_key", cacheKey))
+			}
+		}
+	}
+
+	return u.withPageImageURLs(ctx, products)
+}
+
+func (u *usecase) UpdateProduct(ctx context.Context, id string, input model.UpdateProductInput, precondition model.VersionPrecondition) (*model.Product, error) {
+	product, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		u.logger.Error("Failed to get product for update",
+			zap.Error(err),
+			zap.String("id", id))
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	return u.applyUpdate(ctx, product, input, precondition)
+}
+
+func (u *usecase) PatchProduct(ctx context.Context, id string, patch []byte, precondition model.VersionPrecondition) (*model.Product, error) {
+	product, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		u.logger.Error("Failed to get product for patch",
+			zap.Error(err),
+			zap.String("id", id))
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	// Apply the merge patch to the editable representation of the product
+	current, err := json.Marshal(model.UpdateProductInput{
+		ProductName:        product.ProductName,
+		ProductDescription: product.ProductDescription,
+		ProductImages:      utils.JSONToStringSlice(product.ProductImages),
+		ProductPrice:       product.ProductPrice,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal product: %w", err)
+	}
+
+	merged, err := utils.MergePatch(current, patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed merge patch: %v", ErrInvalidInput, err)
+	}
+
+	var input model.UpdateProductInput
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := u.validate.Struct(input); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	return u.applyUpdate(ctx, product, input, precondition)
+}
+
+func (u *usecase) DeleteProduct(ctx context.Context, id string, precondition model.VersionPrecondition) error {
+	product, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		u.logger.Error("Failed to get product for delete",
+			zap.Error(err),
+			zap.String("id", id))
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+
+	if !precondition.Matches(product.Version) {
+		return fmt.Errorf("failed to delete product: %w", repository.ErrVersionConflict)
+	}
+
+	if err := u.repo.Delete(ctx, id, product.Version); err != nil {
+		u.logger.Error("Failed to delete product",
+			zap.Error(err),
+			zap.String("id", id))
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+
+	u.invalidateProductCache(ctx, id)
+	u.invalidateProductListCache(ctx, product.UserID.String())
+
+	return nil
+}
+
+func (u *usecase) GetTrash(ctx context.Context, userID string) ([]model.Product, error) {
+	products, err := u.repo.GetTrash(ctx, userID)
+	if err != nil {
+		u.logger.Error("Failed to get trashed products",
+			zap.Error(err),
+			zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to get trashed products: %w", err)
+	}
+
+	for i := range products {
+		if err := u.resolveImageURLs(ctx, &products[i]); err != nil {
+			return nil, err
+		}
+	}
 	return products, nil
 }
 
+func (u *usecase) RestoreProduct(ctx context.Context, id string) (*model.Product, error) {
+	if err := u.repo.Restore(ctx, id); err != nil {
+		u.logger.Error("Failed to restore product",
+			zap.Error(err),
+			zap.String("id", id))
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	u.invalidateProductCache(ctx, id)
+
+	product, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get restored product: %w", err)
+	}
+
+	u.invalidateProductListCache(ctx, product.UserID.String())
+
+	return u.withImageURLs(ctx, product)
+}
+
+// GetImageStatus reports the processing state of each current image of a
+// product. Images the processor has not picked up yet, including those
+// replaced by an edit since, are reported as pending.
+func (u *usecase) GetImageStatus(ctx context.Context, id string) (*model.ProductImageStatusReport, error) {
+	product, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	statuses, err := u.imageStatusRepo.GetByProductID(ctx, id)
+	if err != nil {
+		u.logger.Error("Failed to get image statuses",
+			zap.Error(err),
+			zap.String("product_id", id))
+		return nil, fmt.Errorf("failed to get image statuses: %w", err)
+	}
+
+	byPosition := make(map[int]model.ProductImageStatus, len(statuses))
+	for _, status := range statuses {
+		byPosition[status.Position] = status
+	}
+
+	sourceURLs := utils.JSONToStringSlice(product.ProductImages)
+	report := &model.ProductImageStatusReport{
+		ProductID:             product.ID,
+		ImageProcessingStatus: product.ImageProcessingStatus,
+		Images:                make([]model.ProductImageStatus, 0, len(sourceURLs)),
+	}
+	for position, sourceURL := range sourceURLs {
+		status, ok := byPosition[position]
+		if !ok || status.SourceURL != sourceURL {
+			status = model.ProductImageStatus{
+				ProductID:  product.ID,
+				Position:   position,
+				SourceURL:  sourceURL,
+				Status:     model.ImageStatusPending,
+				Renditions: utils.StringMapToJSON(nil),
+				Variants:   datatypes.JSON("[]"),
+				Metadata:   utils.StringMapToJSON(nil),
+			}
+		}
+		if err := u.resolveStatusURLs(ctx, &status); err != nil {
+			return nil, err
+		}
+		report.Images = append(report.Images, status)
+	}
+
+	return report, nil
+}
+
+// applyUpdate replaces the editable fields of a product and, when the source
+// images changed, discards the stale compressed images and requests new ones.
+// The write only succeeds if the product is still at the version that was read.
+func (u *usecase) applyUpdate(ctx context.Context, product *model.Product, input model.UpdateProductInput, precondition model.VersionPrecondition) (*model.Product, error) {
+	if !precondition.Matches(product.Version) {
+		return nil, fmt.Errorf("failed to update product: %w", repository.ErrVersionConflict)
+	}
+
+	input.ProductImages = u.imageSources(input.ProductImages)
+	previousImages := utils.JSONToStringSlice(product.ProductImages)
+	imagesChanged := !slices.Equal(previousImages, input.ProductImages)
+
+	product.ProductName = input.ProductName
+	product.ProductDescription = input.ProductDescription
+	product.ProductPrice = input.ProductPrice
+	product.UpdatedAt = time.Now()
+	if imagesChanged {
+		product.ProductImages = utils.StringSliceToJSON(input.ProductImages)
+		product.CompressedProductImages = nil
+		product.ImageVariants = nil
+		product.ImageProcessingStatus = model.ImageProcessingPending
+	}
+
+	var outbox []model.OutboxMessage
+	if imagesChanged {
+		if err := u.checkUploads(ctx, product.UserID.String(), input.ProductImages, previousImages); err != nil {
+			return nil, err
+		}
+		task, err := newImageProcessingMessage(product.ID, input.ProductImages)
+		if err != nil {
+			return nil, err
+		}
+		outbox = append(outbox, task)
+	}
+
+	if err := u.repo.Update(ctx, product, product.Version, outbox...); err != nil {
+		u.logger.Error("Failed to update product in database",
+			zap.Error(err),
+			zap.String("product_id", product.ID.String()))
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+
+	u.invalidateProductCache(ctx, product.ID.String())
+	u.invalidateProductListCache(ctx, product.UserID.String())
+
+	return u.withImageURLs(ctx, product)
+}
+
+// newImageProcessingMessage builds the outbox message asking the image
+// processor to compress the given images.
+func newImageProcessingMessage(productID uuid.UUID, imageURLs []string) (model.OutboxMessage, error) {
+	taskData, err := json.Marshal(model.ImageProcessingTask{
+		ProductID: productID.String(),
+		ImageURLs: imageURLs,
+	})
+	if err != nil {
+		return model.OutboxMessage{}, fmt.Errorf("failed to marshal image processing task: %w", err)
+	}
+
+	return model.OutboxMessage{
+		AggregateID:   productID,
+		Topic:         model.ImageProcessingTopic,
+		Payload:       taskData,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
 // Add this new method for cache invalidation
 func (u *usecase) invalidateProductCache(ctx context.Context, productID string) {
-	cacheKey := fmt.Sprintf("product:%s", productID)
+	cacheKey := model.ProductCacheKey(productID)
 	if err := u.redisClient.Del(ctx, cacheKey).Err(); err != nil {
 		u.logger.Warn("Failed to invalidate product cache",
 			zap.Error(err),
@@ -168,3 +461,37 @@ func (u *usecase) invalidateProductCache(ctx context.Context, productID string)
 			zap.String("cache_key", cacheKey))
 	}
 }
+
+// productListCacheKey derives the cache key of a listing from the filter and
+// page. Keys embed a per-user generation so bumping it drops every cached page.
+func (u *usecase) productListCacheKey(ctx context.Context, userID string, filter model.ProductFilter, page model.PageRequest) (string, error) {
+	generation, err := u.redisClient.Get(ctx, productListGenerationKey(userID))
+	if errors.Is(err, goredis.Nil) {
+		generation = "0"
+	} else if err != nil {
+		return "", err
+	}
+
+	query, err := json.Marshal(struct {
+		Filter model.ProductFilter `json:"filter"`
+		Page   model.PageRequest   `json:"page"`
+	}{filter, page})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(query)
+	return fmt.Sprintf("products:%s:%s:%s", userID, generation, hex.EncodeToString(hash[:])), nil
+}
+
+func (u *usecase) invalidateProductListCache(ctx context.Context, userID string) {
+	if _, err := u.redisClient.Incr(ctx, productListGenerationKey(userID)); err != nil {
+		u.logger.Warn("Failed to invalidate product list cache",
+			zap.Error(err),
+			zap.String("user_id", userID))
+	}
+}
+
+func productListGenerationKey(userID string) string {
+	return fmt.Sprintf("products:gen:%s", userID)
+}
@@ -0,0 +1,147 @@
+// pkg/imagemeta/exif.go
+
+package imagemeta
+
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+// EXIF tags read from IFD0 and the Exif sub-IFD
+const (
+	tagMake               = 0x010f
+	tagModel              = 0x0110
+	tagOrientation        = 0x0112
+	tagExifIFD            = 0x8769
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+)
+
+const (
+	typeASCII = 2
+	typeShort = 3
+	typeLong  = 4
+)
+
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+// tiff reads the TIFF structure EXIF data is stored in.
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	tag, typ uint16
+	count    uint32
+	// value holds the value itself if it fits in four bytes, its offset otherwise
+	value []byte
+}
+
+// readEXIF reads the fields kept from a TIFF-structured EXIF block.
+func readEXIF(data []byte, meta *Metadata) {
+	if len(data) < 8 {
+		return
+	}
+	t := tiff{data: data}
+	switch string(data[0:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return
+	}
+
+	var dateTimeOriginal, offsetTimeOriginal string
+	for _, entry := range t.ifd(t.order.Uint32(data[4:])) {
+		switch entry.tag {
+		case tagMake:
+			meta.Make = t.ascii(entry)
+		case tagModel:
+			meta.Model = t.ascii(entry)
+		case tagOrientation:
+			if orientation := t.uint(entry); orientation >= 1 && orientation <= 8 {
+				meta.Orientation = int(orientation)
+			}
+		case tagExifIFD:
+			for _, exifEntry := range t.ifd(t.uint(entry)) {
+				switch exifEntry.tag {
+				case tagDateTimeOriginal:
+					dateTimeOriginal = t.ascii(exifEntry)
+				case tagOffsetTimeOriginal:
+					offsetTimeOriginal = t.ascii(exifEntry)
+				}
+			}
+		}
+	}
+	meta.CapturedAt = captureTime(dateTimeOriginal, offsetTimeOriginal)
+}
+
+// captureTime formats an EXIF date, which has no time zone unless the
+// file records its offset separately.
+func captureTime(dateTime, offset string) string {
+	if offset != "" {
+		if t, err := time.Parse(exifTimeLayout+"-07:00", dateTime+offset); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	if t, err := time.Parse(exifTimeLayout, dateTime); err == nil {
+		return t.Format("2006-01-02T15:04:05")
+	}
+	return ""
+}
+
+func (t tiff) ifd(offset uint32) []ifdEntry {
+	if offset < 8 || int(offset)+2 > len(t.data) {
+		return nil
+	}
+	count := int(t.order.Uint16(t.data[offset:]))
+	start := int(offset) + 2
+	if start+12*count > len(t.data) {
+		return nil
+	}
+
+	entries := make([]ifdEntry, count)
+	for i := range entries {
+		p := t.data[start+12*i:]
+		entries[i] = ifdEntry{
+			tag:   t.order.Uint16(p[0:]),
+			typ:   t.order.Uint16(p[2:]),
+			count: t.order.Uint32(p[4:]),
+			value: p[8:12],
+		}
+	}
+	return entries
+}
+
+func (t tiff) uint(entry ifdEntry) uint32 {
+	switch entry.typ {
+	case typeShort:
+		return uint32(t.order.Uint16(entry.value))
+	case typeLong:
+		return t.order.Uint32(entry.value)
+	}
+	return 0
+}
+
+func (t tiff) ascii(entry ifdEntry) string {
+	if entry.typ != typeASCII {
+		return ""
+	}
+	value := entry.value
+	if entry.count > 4 {
+		offset := t.order.Uint32(entry.value)
+		if uint64(offset)+uint64(entry.count) > uint64(len(t.data)) {
+			return ""
+		}
+		value = t.data[offset : offset+entry.count]
+	} else {
+		value = value[:entry.count]
+	}
+	return strings.TrimSpace(strings.TrimRight(string(value), "\x00"))
+}
@@ -0,0 +1,313 @@
+// pkg/imagemeta/icc.go
+
+package imagemeta
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"math"
+	"unicode/utf16"
+)
+
+// ErrUnsupportedProfile is returned for profiles that cannot be converted to
+// sRGB: anything but RGB matrix/TRC profiles, such as CMYK or LUT-based ones.
+var ErrUnsupportedProfile = errors.New("imagemeta: unsupported ICC profile")
+
+const iccHeaderSize = 128
+
+// maxColorant bounds the XYZ components of the colorants; those of real
+// profiles lie within [0, 1]
+const maxColorant = 2
+
+// maxGamma bounds the exponents of parametric curves
+const maxGamma = 16
+
+// srgbFromXYZ converts D50 XYZ, the ICC profile connection space, to linear
+// sRGB; srgbToXYZ is its inverse, the Bradford-adapted sRGB primaries.
+var (
+	srgbFromXYZ = [3][3]float64{
+		{3.1338561, -1.6168667, -0.4906146},
+		{-0.9787684, 1.9161415, 0.0334540},
+		{0.0719453, -0.2289914, 1.4052427},
+	}
+	srgbToXYZ = [3][3]float64{
+		{0.4360747, 0.3850649, 0.1430804},
+		{0.2225045, 0.7168786, 0.0606169},
+		{0.0139322, 0.0971045, 0.7141733},
+	}
+)
+
+// Profile is a parsed ICC color profile.
+type Profile struct {
+	Description string
+	ColorSpace  string
+
+	// Set for RGB matrix/TRC profiles only
+	matrixShaper bool
+	toXYZ        [3][3]float64
+	// curves map each 8-bit channel value to linear light
+	curves [3][256]float64
+}
+
+// ParseICCProfile parses the tags of an ICC profile needed to describe it and
+// to convert its colors to sRGB.
+func ParseICCProfile(data []byte) (*Profile, error) {
+	if len(data) < iccHeaderSize+4 || string(data[36:40]) != "acsp" {
+		return nil, errors.New("imagemeta: invalid ICC profile")
+	}
+
+	p := &Profile{ColorSpace: trimSignature(data[16:20])}
+	tags := make(map[string][]byte)
+	count := int(binary.BigEndian.Uint32(data[iccHeaderSize:]))
+	for i := 0; i < count; i++ {
+		entry := iccHeaderSize + 4 + 12*i
+		if entry+12 > len(data) {
+			break
+		}
+		offset := binary.BigEndian.Uint32(data[entry+4:])
+		size := binary.BigEndian.Uint32(data[entry+8:])
+		if uint64(offset)+uint64(size) > uint64(len(data)) {
+			continue
+		}
+		tags[string(data[entry:entry+4])] = data[offset : offset+size]
+	}
+
+	p.Description = parseText(tags["desc"])
+
+	if p.ColorSpace != "RGB" || string(data[20:24]) != "XYZ " {
+		return p, nil
+	}
+	for channel, name := range [3]string{"r", "g", "b"} {
+		x, y, z, ok := parseXYZ(tags[name+"XYZ"])
+		if !ok {
+			return p, nil
+		}
+		for _, component := range [3]float64{x, y, z} {
+			if math.Abs(component) > maxColorant {
+				return p, nil
+			}
+		}
+		p.toXYZ[0][channel], p.toXYZ[1][channel], p.toXYZ[2][channel] = x, y, z
+
+		curve, ok := parseCurve(tags[name+"TRC"])
+		if !ok {
+			return p, nil
+		}
+		for v := range p.curves[channel] {
+			linear := curve(float64(v) / 255)
+			if math.IsNaN(linear) || math.IsInf(linear, 0) {
+				return p, nil
+			}
+			p.curves[channel][v] = math.Max(0, math.Min(1, linear))
+		}
+	}
+	p.matrixShaper = true
+	return p, nil
+}
+
+// IsSRGB reports whether the profile describes sRGB, or a space close
+// enough to it that converting would not visibly change any color.
+func (p *Profile) IsSRGB() bool {
+	if !p.matrixShaper {
+		return false
+	}
+	for i := range p.toXYZ {
+		for j := range p.toXYZ[i] {
+			if math.Abs(p.toXYZ[i][j]-srgbToXYZ[i][j]) > 0.005 {
+				return false
+			}
+		}
+	}
+	for _, curve := range p.curves {
+		for v, linear := range curve {
+			if math.Abs(linear-srgbDecode(float64(v)/255)) > 0.005 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ConvertToSRGB converts an image encoded in the profile's color space to
+// sRGB, clipping colors outside the sRGB gamut. Alpha is kept as is.
+func (p *Profile) ConvertToSRGB(img image.Image) (*image.NRGBA, error) {
+	if !p.matrixShaper {
+		return nil, ErrUnsupportedProfile
+	}
+
+	var m [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				m[i][j] += srgbFromXYZ[i][k] * p.toXYZ[k][j]
+			}
+		}
+	}
+
+	// Encoding linear light back to 8 bits goes through a table fine
+	// enough to tell apart the darkest sRGB values
+	const encodeSteps = 1 << 14
+	var encode [encodeSteps + 1]uint8
+	for i := range encode {
+		encode[i] = uint8(math.Round(255 * srgbEncode(float64(i)/encodeSteps)))
+	}
+
+	bounds := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(out, out.Rect, img, bounds.Min, draw.Src)
+	for i := 0; i < len(out.Pix); i += 4 {
+		r := p.curves[0][out.Pix[i+0]]
+		g := p.curves[1][out.Pix[i+1]]
+		b := p.curves[2][out.Pix[i+2]]
+		for c := 0; c < 3; c++ {
+			linear := m[c][0]*r + m[c][1]*g + m[c][2]*b
+			// The comparisons map NaN to 0 as well
+			switch {
+			case !(linear > 0):
+				linear = 0
+			case linear > 1:
+				linear = 1
+			}
+			out.Pix[i+c] = encode[int(linear*encodeSteps+0.5)]
+		}
+	}
+	return out, nil
+}
+
+func srgbDecode(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func srgbEncode(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+func parseXYZ(tag []byte) (x, y, z float64, ok bool) {
+	if len(tag) < 20 || string(tag[0:4]) != "XYZ " {
+		return 0, 0, 0, false
+	}
+	return s15Fixed16(tag[8:]), s15Fixed16(tag[12:]), s15Fixed16(tag[16:]), true
+}
+
+// parseCurve parses a curv or para tone curve into a function from encoded
+// values to linear light, both in [0, 1].
+func parseCurve(tag []byte) (func(float64) float64, bool) {
+	if len(tag) < 12 {
+		return nil, false
+	}
+	switch string(tag[0:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if len(tag) < 12+2*n {
+			return nil, false
+		}
+		switch n {
+		case 0:
+			return func(v float64) float64 { return v }, true
+		case 1:
+			gamma := float64(binary.BigEndian.Uint16(tag[12:])) / 256
+			return func(v float64) float64 { return math.Pow(v, gamma) }, true
+		}
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+2*i:])) / 65535
+		}
+		return func(v float64) float64 {
+			pos := v * float64(n-1)
+			i := int(pos)
+			if i >= n-1 {
+				return table[n-1]
+			}
+			frac := pos - float64(i)
+			return table[i]*(1-frac) + table[i+1]*frac
+		}, true
+
+	case "para":
+		functionType := int(binary.BigEndian.Uint16(tag[8:]))
+		paramCounts := [5]int{1, 3, 4, 5, 7}
+		if functionType >= len(paramCounts) || len(tag) < 12+4*paramCounts[functionType] {
+			return nil, false
+		}
+		// Missing parameters take the values that reduce each function
+		// to the simpler ones
+		params := [7]float64{1, 1, 0, 1, 0, 0, 0}
+		for i := 0; i < paramCounts[functionType]; i++ {
+			params[i] = s15Fixed16(tag[12+4*i:])
+		}
+		g, a, b, c, d, e, f := params[0], params[1], params[2], params[3], params[4], params[5], params[6]
+		// A zero a would divide by zero below; gammas outside (0, maxGamma]
+		// turn black into infinity
+		if g <= 0 || g > maxGamma || (functionType > 0 && a == 0) {
+			return nil, false
+		}
+		switch functionType {
+		case 1:
+			c, d = 0, -b/a
+		case 2:
+			d, e, f = -b/a, c, c
+			c = 0
+		}
+		return func(v float64) float64 {
+			if functionType == 0 {
+				return math.Pow(v, g)
+			}
+			if v >= d {
+				return math.Pow(math.Max(a*v+b, 0), g) + e
+			}
+			return c*v + f
+		}, true
+	}
+	return nil, false
+}
+
+// parseText reads the text of a v2 textDescriptionType or v4
+// multiLocalizedUnicodeType tag, taking the first localization.
+func parseText(tag []byte) string {
+	if len(tag) < 12 {
+		return ""
+	}
+	switch string(tag[0:4]) {
+	case "desc":
+		n := binary.BigEndian.Uint32(tag[8:])
+		if uint64(12)+uint64(n) > uint64(len(tag)) {
+			return ""
+		}
+		return trimSignature(tag[12 : 12+n])
+	case "mluc":
+		if len(tag) < 28 || binary.BigEndian.Uint32(tag[8:]) == 0 {
+			return ""
+		}
+		length := binary.BigEndian.Uint32(tag[20:])
+		offset := binary.BigEndian.Uint32(tag[24:])
+		if uint64(offset)+uint64(length) > uint64(len(tag)) {
+			return ""
+		}
+		text := tag[offset : offset+length]
+		units := make([]uint16, len(text)/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(text[2*i:])
+		}
+		return trimSignature([]byte(string(utf16.Decode(units))))
+	}
+	return ""
+}
+
+func trimSignature(b []byte) string {
+	end := len(b)
+	for end > 0 && (b[end-1] == 0 || b[end-1] == ' ') {
+		end--
+	}
+	return string(b[:end])
+}
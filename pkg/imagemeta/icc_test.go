@@ -0,0 +1,286 @@
+// pkg/imagemeta/icc_test.go
+
+package imagemeta
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+	"unicode/utf16"
+)
+
+// iccTag is a tag of a test profile.
+type iccTag struct {
+	sig  string
+	data []byte
+}
+
+// buildProfile assembles an ICC profile of a color space from its tags.
+func buildProfile(colorSpace string, tags ...iccTag) []byte {
+	data := make([]byte, iccHeaderSize+4+12*len(tags))
+	copy(data[16:20], colorSpace)
+	copy(data[20:24], "XYZ ")
+	copy(data[36:40], "acsp")
+	binary.BigEndian.PutUint32(data[iccHeaderSize:], uint32(len(tags)))
+	for i, tag := range tags {
+		entry := data[iccHeaderSize+4+12*i:]
+		copy(entry[0:4], tag.sig)
+		binary.BigEndian.PutUint32(entry[4:], uint32(len(data)))
+		binary.BigEndian.PutUint32(entry[8:], uint32(len(tag.data)))
+		data = append(data, tag.data...)
+	}
+	return data
+}
+
+func s15(v float64) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(int32(math.Round(v*65536))))
+}
+
+func xyzTag(x, y, z float64) []byte {
+	tag := append([]byte("XYZ \x00\x00\x00\x00"), s15(x)...)
+	tag = append(tag, s15(y)...)
+	return append(tag, s15(z)...)
+}
+
+func paraTag(functionType uint16, params ...float64) []byte {
+	tag := []byte("para\x00\x00\x00\x00")
+	tag = binary.BigEndian.AppendUint16(tag, functionType)
+	tag = append(tag, 0, 0)
+	for _, param := range params {
+		tag = append(tag, s15(param)...)
+	}
+	return tag
+}
+
+func curvTag(values ...uint16) []byte {
+	tag := []byte("curv\x00\x00\x00\x00")
+	tag = binary.BigEndian.AppendUint32(tag, uint32(len(values)))
+	for _, v := range values {
+		tag = binary.BigEndian.AppendUint16(tag, v)
+	}
+	return tag
+}
+
+func descTag(text string) []byte {
+	tag := []byte("desc\x00\x00\x00\x00")
+	tag = binary.BigEndian.AppendUint32(tag, uint32(len(text)+1))
+	return append(append(tag, text...), 0)
+}
+
+func mlucTag(text string) []byte {
+	units := utf16.Encode([]rune(text))
+	tag := []byte("mluc\x00\x00\x00\x00")
+	tag = binary.BigEndian.AppendUint32(tag, 1)
+	tag = binary.BigEndian.AppendUint32(tag, 12)
+	tag = append(tag, "enUS"...)
+	tag = binary.BigEndian.AppendUint32(tag, uint32(2*len(units)))
+	tag = binary.BigEndian.AppendUint32(tag, 28)
+	for _, unit := range units {
+		tag = binary.BigEndian.AppendUint16(tag, unit)
+	}
+	return tag
+}
+
+// srgbCurve is the sRGB tone curve as a parametric curve.
+var srgbCurve = paraTag(3, 2.4, 1/1.055, 0.055/1.055, 1/12.92, 0.04045)
+
+// matrixProfile builds an RGB profile of the colorants of toXYZ sharing one
+// tone curve.
+func matrixProfile(toXYZ [3][3]float64, curve []byte, extra ...iccTag) []byte {
+	tags := extra
+	for channel, name := range [3]string{"r", "g", "b"} {
+		tags = append(tags,
+			iccTag{name + "XYZ", xyzTag(toXYZ[0][channel], toXYZ[1][channel], toXYZ[2][channel])},
+			iccTag{name + "TRC", curve})
+	}
+	return buildProfile("RGB ", tags...)
+}
+
+// displayP3ToXYZ holds the D50-adapted Display P3 colorants.
+var displayP3ToXYZ = [3][3]float64{
+	{0.5151, 0.2920, 0.1571},
+	{0.2412, 0.6922, 0.0666},
+	{-0.0011, 0.0419, 0.7843},
+}
+
+func TestParseICCProfileInvalid(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"truncated": make([]byte, iccHeaderSize),
+		"no magic":  make([]byte, iccHeaderSize+4),
+	} {
+		if _, err := ParseICCProfile(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestParseICCProfileDescription(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  []byte
+		want string
+	}{
+		{"desc", descTag("Display P3"), "Display P3"},
+		{"mluc", mlucTag("Adobe RGB (1998)"), "Adobe RGB (1998)"},
+		{"truncated desc", descTag("Display P3")[:14], ""},
+		{"unknown type", []byte("text\x00\x00\x00\x00abcd"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := ParseICCProfile(buildProfile("RGB ", iccTag{"desc", tt.tag}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profile.Description != tt.want {
+				t.Errorf("description = %q, want %q", profile.Description, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSRGB(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"sRGB", matrixProfile(srgbToXYZ, srgbCurve), true},
+		{"Display P3", matrixProfile(displayP3ToXYZ, srgbCurve), false},
+		{"gamma 1.8", matrixProfile(srgbToXYZ, curvTag(1<<8|0xcd)), false},
+		{"CMYK", buildProfile("CMYK"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := ParseICCProfile(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := profile.IsSRGB(); got != tt.want {
+				t.Errorf("IsSRGB() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCurve(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  []byte
+		// want holds the expected values at 0, 0.5 and 1
+		want [3]float64
+	}{
+		{"identity", curvTag(), [3]float64{0, 0.5, 1}},
+		{"gamma 2", curvTag(2 << 8), [3]float64{0, 0.25, 1}},
+		{"table", curvTag(0, 0x4000, 0xffff), [3]float64{0, 0x4000 / 65535.0, 1}},
+		{"para gamma", paraTag(0, 2), [3]float64{0, 0.25, 1}},
+		{"para type 1", paraTag(1, 1, 2, -1), [3]float64{0, 0, 1}},
+		{"para type 2", paraTag(2, 1, 1, 0, 0.25), [3]float64{0.25, 0.75, 1.25}},
+		{"para sRGB", srgbCurve, [3]float64{0, srgbDecode(0.5), 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			curve, ok := parseCurve(tt.tag)
+			if !ok {
+				t.Fatal("curve rejected")
+			}
+			for i, v := range [3]float64{0, 0.5, 1} {
+				if got := curve(v); math.Abs(got-tt.want[i]) > 1e-3 {
+					t.Errorf("curve(%v) = %v, want %v", v, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCurveRejectsInvalid(t *testing.T) {
+	tests := map[string][]byte{
+		"short":            []byte("curv"),
+		"truncated table":  curvTag(0, 1, 2)[:14],
+		"unknown type":     []byte("sf32\x00\x00\x00\x00\x00\x00\x00\x00"),
+		"unknown function": paraTag(5, 1, 1, 1, 1, 1, 1, 1),
+		"missing params":   paraTag(3, 2.4),
+		"negative gamma":   paraTag(0, -2),
+		"zero gamma":       paraTag(0, 0),
+		"huge gamma":       paraTag(0, 1000),
+		"zero a":           paraTag(1, 2.2, 0, 0.5),
+	}
+	for name, tag := range tests {
+		if _, ok := parseCurve(tag); ok {
+			t.Errorf("%s: curve accepted", name)
+		}
+	}
+}
+
+// TestConvertToSRGBRejectsHostileProfiles checks that profiles which would
+// turn pixels into NaN or infinity are not converted.
+func TestConvertToSRGBRejectsHostileProfiles(t *testing.T) {
+	var zeroRed [3][3]float64 = srgbToXYZ
+	zeroRed[0][0], zeroRed[1][0], zeroRed[2][0] = 0, 0, 0
+	var huge [3][3]float64 = srgbToXYZ
+	huge[0][0] = 1000
+
+	tests := map[string][]byte{
+		"negative gamma and zero colorant": matrixProfile(zeroRed, paraTag(0, -2.2)),
+		"out of range colorant":            matrixProfile(huge, srgbCurve),
+		"missing curve":                    buildProfile("RGB ", iccTag{"rXYZ", xyzTag(0.4, 0.2, 0)}),
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Pix = []uint8{0, 0, 0, 255, 255, 128, 7, 255}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			profile, err := ParseICCProfile(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := profile.ConvertToSRGB(img); !errors.Is(err, ErrUnsupportedProfile) {
+				t.Errorf("ConvertToSRGB() error = %v, want ErrUnsupportedProfile", err)
+			}
+		})
+	}
+}
+
+func TestConvertToSRGB(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	img.Set(0, 0, color.NRGBA{0, 0, 0, 255})
+	img.Set(1, 0, color.NRGBA{255, 0, 0, 128})
+	img.Set(2, 0, color.NRGBA{120, 200, 30, 255})
+
+	t.Run("sRGB is unchanged", func(t *testing.T) {
+		profile, err := ParseICCProfile(matrixProfile(srgbToXYZ, srgbCurve))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := profile.ConvertToSRGB(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range img.Pix {
+			if diff := int(out.Pix[i]) - int(img.Pix[i]); diff < -1 || diff > 1 {
+				t.Errorf("Pix[%d] = %d, want %d", i, out.Pix[i], img.Pix[i])
+			}
+		}
+	})
+
+	t.Run("Display P3 red is clipped", func(t *testing.T) {
+		profile, err := ParseICCProfile(matrixProfile(displayP3ToXYZ, srgbCurve))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := profile.ConvertToSRGB(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		red := out.NRGBAAt(1, 0)
+		if red.R != 255 || red.G != 0 || red.B != 0 || red.A != 128 {
+			t.Errorf("red = %v, want {255 0 0 128}", red)
+		}
+		if black := out.NRGBAAt(0, 0); black != (color.NRGBA{0, 0, 0, 255}) {
+			t.Errorf("black = %v", black)
+		}
+	})
+}
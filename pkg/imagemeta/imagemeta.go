@@ -0,0 +1,152 @@
+// pkg/imagemeta/imagemeta.go
+
+// Package imagemeta reads the EXIF and ICC metadata embedded in JPEG, PNG and
+// WebP files.
+//
+// Only the fields needed to display an image correctly and the capture details
+// worth keeping are extracted; everything else, GPS position included, is
+// ignored. Malformed metadata is skipped rather than reported, as it must not
+// keep an otherwise decodable image from being processed.
+package imagemeta
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+// maxICCProfileSize bounds the decompressed size of PNG ICC profiles
+const maxICCProfileSize = 4 << 20
+
+// Metadata is the metadata read from an image file.
+type Metadata struct {
+	// Orientation is the EXIF orientation, 1 to 8; 0 if absent
+	Orientation int
+	Make        string
+	Model       string
+	// CapturedAt is the EXIF DateTimeOriginal, formatted as RFC 3339 when
+	// the file records its time zone offset
+	CapturedAt string
+	// ICCProfile is the raw embedded color profile
+	ICCProfile []byte
+}
+
+// Read extracts the metadata of a JPEG, PNG or WebP file. Other formats
+// yield empty metadata.
+func Read(data []byte) Metadata {
+	var meta Metadata
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		readJPEG(data, &meta)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		readPNG(data, &meta)
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		readWebP(data, &meta)
+	}
+	return meta
+}
+
+func readJPEG(data []byte, meta *Metadata) {
+	type iccChunk struct {
+		seq  byte
+		data []byte
+	}
+	var iccChunks []iccChunk
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xff:
+			// Fill byte
+			i++
+			continue
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7):
+			i += 2
+			continue
+		case marker == 0xda || marker == 0xd9:
+			// Metadata precedes the scan data
+			i = len(data)
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			break
+		}
+		segment := data[i+4 : i+2+length]
+		switch {
+		case marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")):
+			readEXIF(segment[6:], meta)
+		case marker == 0xe2 && bytes.HasPrefix(segment, []byte("ICC_PROFILE\x00")) && len(segment) > 14:
+			iccChunks = append(iccChunks, iccChunk{seq: segment[12], data: segment[14:]})
+		}
+		i += 2 + length
+	}
+
+	// Large profiles are split over several segments, numbered from 1
+	sort.SliceStable(iccChunks, func(a, b int) bool { return iccChunks[a].seq < iccChunks[b].seq })
+	for _, chunk := range iccChunks {
+		meta.ICCProfile = append(meta.ICCProfile, chunk.data...)
+	}
+}
+
+func readPNG(data []byte, meta *Metadata) {
+	for i := 8; i+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		chunkType := string(data[i+4 : i+8])
+		if length < 0 || i+12+length > len(data) {
+			return
+		}
+		chunk := data[i+8 : i+8+length]
+		switch chunkType {
+		case "eXIf":
+			readEXIF(chunk, meta)
+		case "iCCP":
+			// Profile name, null separator, compression method, zlib stream
+			if sep := bytes.IndexByte(chunk, 0); sep >= 0 && sep+2 <= len(chunk) {
+				meta.ICCProfile = inflate(chunk[sep+2:])
+			}
+		case "IEND":
+			return
+		}
+		i += 12 + length
+	}
+}
+
+func readWebP(data []byte, meta *Metadata) {
+	for i := 12; i+8 <= len(data); {
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if size < 0 || i+8+size > len(data) {
+			return
+		}
+		chunk := data[i+8 : i+8+size]
+		switch fourCC {
+		case "EXIF":
+			// Some writers keep the JPEG APP1 prefix
+			readEXIF(bytes.TrimPrefix(chunk, []byte("Exif\x00\x00")), meta)
+		case "ICCP":
+			meta.ICCProfile = append([]byte(nil), chunk...)
+		}
+		i += 8 + size + size&1
+	}
+}
+
+func inflate(data []byte) []byte {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+
+	profile, err := io.ReadAll(io.LimitReader(r, maxICCProfileSize+1))
+	if err != nil || len(profile) > maxICCProfileSize {
+		return nil
+	}
+	return profile
+}
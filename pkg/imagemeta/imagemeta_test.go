@@ -0,0 +1,216 @@
+// pkg/imagemeta/imagemeta_test.go
+
+package imagemeta
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+)
+
+// exifEntry is an IFD entry of a test EXIF block; values longer than four
+// bytes are stored after the IFDs.
+type exifEntry struct {
+	tag, typ uint16
+	value    []byte
+}
+
+func shortEntry(tag uint16, v uint16, order binary.ByteOrder) exifEntry {
+	value := make([]byte, 2)
+	order.PutUint16(value, v)
+	return exifEntry{tag, typeShort, value}
+}
+
+func asciiEntry(tag uint16, s string) exifEntry {
+	return exifEntry{tag, typeASCII, append([]byte(s), 0)}
+}
+
+// buildEXIF assembles a TIFF-structured EXIF block with IFD0 and, when
+// given, an Exif sub-IFD.
+func buildEXIF(order binary.ByteOrder, ifd0, exifIFD []exifEntry) []byte {
+	ifdSize := func(entries []exifEntry) int { return 2 + 12*len(entries) + 4 }
+	if exifIFD != nil {
+		ifd0 = append(ifd0, exifEntry{tag: tagExifIFD, typ: typeLong})
+	}
+	ifd0Offset := 8
+	exifOffset := ifd0Offset + ifdSize(ifd0)
+	dataOffset := exifOffset
+	if exifIFD != nil {
+		dataOffset += ifdSize(exifIFD)
+	}
+
+	data := make([]byte, dataOffset)
+	if order == binary.LittleEndian {
+		copy(data, "II")
+	} else {
+		copy(data, "MM")
+	}
+	order.PutUint16(data[2:], 42)
+	order.PutUint32(data[4:], uint32(ifd0Offset))
+
+	writeIFD := func(offset int, entries []exifEntry) {
+		order.PutUint16(data[offset:], uint16(len(entries)))
+		for i, entry := range entries {
+			p := data[offset+2+12*i:]
+			order.PutUint16(p[0:], entry.tag)
+			order.PutUint16(p[2:], entry.typ)
+			order.PutUint32(p[4:], uint32(len(entry.value)))
+			switch {
+			case entry.tag == tagExifIFD:
+				order.PutUint32(p[4:], 1)
+				order.PutUint32(p[8:], uint32(exifOffset))
+			case len(entry.value) <= 4:
+				copy(p[8:12], entry.value)
+			default:
+				order.PutUint32(p[8:], uint32(len(data)))
+				data = append(data, entry.value...)
+			}
+		}
+	}
+	writeIFD(ifd0Offset, ifd0)
+	if exifIFD != nil {
+		writeIFD(exifOffset, exifIFD)
+	}
+	return data
+}
+
+func testEXIF(order binary.ByteOrder) []byte {
+	return buildEXIF(order,
+		[]exifEntry{
+			asciiEntry(tagMake, "Canon"),
+			asciiEntry(tagModel, "EOS R5"),
+			shortEntry(tagOrientation, 6, order),
+		},
+		[]exifEntry{
+			asciiEntry(tagDateTimeOriginal, "2024:05:01 13:45:10"),
+			asciiEntry(tagOffsetTimeOriginal, "+02:00"),
+		})
+}
+
+var wantEXIF = Metadata{
+	Orientation: 6,
+	Make:        "Canon",
+	Model:       "EOS R5",
+	CapturedAt:  "2024-05-01T13:45:10+02:00",
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xff, marker}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func pngChunk(chunkType string, payload []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, payload...)
+	// The CRC is not checked
+	return append(chunk, 0, 0, 0, 0)
+}
+
+func webpChunk(fourCC string, payload []byte) []byte {
+	chunk := append([]byte(fourCC), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func compress(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	profile := []byte("first half|second half")
+
+	jpeg := []byte{0xff, 0xd8}
+	jpeg = append(jpeg, jpegSegment(0xe1, append([]byte("Exif\x00\x00"), testEXIF(binary.BigEndian)...))...)
+	// ICC chunks out of order are put back in sequence
+	jpeg = append(jpeg, jpegSegment(0xe2, append([]byte("ICC_PROFILE\x00\x02\x02"), profile[10:]...))...)
+	jpeg = append(jpeg, jpegSegment(0xe2, append([]byte("ICC_PROFILE\x00\x01\x02"), profile[:10]...))...)
+	jpeg = append(jpeg, 0xff, 0xda, 0, 2, 0xff, 0xd9)
+
+	png := []byte("\x89PNG\r\n\x1a\n")
+	png = append(png, pngChunk("IHDR", make([]byte, 13))...)
+	png = append(png, pngChunk("eXIf", testEXIF(binary.LittleEndian))...)
+	png = append(png, pngChunk("iCCP", append([]byte("icc\x00\x00"), compress(profile)...))...)
+	png = append(png, pngChunk("IEND", nil)...)
+
+	webpBody := []byte("WEBP")
+	webpBody = append(webpBody, webpChunk("VP8X", make([]byte, 10))...)
+	webpBody = append(webpBody, webpChunk("ICCP", profile)...)
+	webpBody = append(webpBody, webpChunk("EXIF", append([]byte("Exif\x00\x00"), testEXIF(binary.LittleEndian)...))...)
+	webp := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(webpBody)))...)
+	webp = append(webp, webpBody...)
+
+	for name, data := range map[string][]byte{"jpeg": jpeg, "png": png, "webp": webp} {
+		t.Run(name, func(t *testing.T) {
+			meta := Read(data)
+			if !bytes.Equal(meta.ICCProfile, profile) {
+				t.Errorf("ICCProfile = %q, want %q", meta.ICCProfile, profile)
+			}
+			if meta.Orientation != wantEXIF.Orientation || meta.Make != wantEXIF.Make ||
+				meta.Model != wantEXIF.Model || meta.CapturedAt != wantEXIF.CapturedAt {
+				t.Errorf("Read() = %+v, want %+v", meta, wantEXIF)
+			}
+		})
+	}
+}
+
+// TestReadMalformed checks that malformed metadata is skipped without
+// panicking.
+func TestReadMalformed(t *testing.T) {
+	exif := testEXIF(binary.BigEndian)
+	tests := map[string][]byte{
+		"unknown format":    []byte("GIF89a"),
+		"truncated jpeg":    {0xff, 0xd8, 0xff, 0xe1, 0xff, 0xff, 'E'},
+		"bad jpeg marker":   {0xff, 0xd8, 0x00, 0x00, 0x00, 0x00},
+		"truncated exif":    append(append([]byte{0xff, 0xd8}, jpegSegment(0xe1, append([]byte("Exif\x00\x00"), exif[:12]...))...), 0xff, 0xd9),
+		"bad byte order":    append(append([]byte{0xff, 0xd8}, jpegSegment(0xe1, []byte("Exif\x00\x00XX\x00\x2a\x00\x00\x00\x08"))...), 0xff, 0xd9),
+		"png chunk too big": append([]byte("\x89PNG\r\n\x1a\n\x7f\xff\xff\xffeXIf"), make([]byte, 8)...),
+		"png bad zlib":      append([]byte("\x89PNG\r\n\x1a\n"), pngChunk("iCCP", []byte("icc\x00\x00garbage"))...),
+		"webp chunk too big": append([]byte("RIFF\x00\x00\x00\x00WEBPEXIF\xff\xff\xff\x7f"),
+			make([]byte, 8)...),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			meta := Read(data)
+			if meta.Orientation != 0 || meta.Make != "" || meta.ICCProfile != nil {
+				t.Errorf("Read() = %+v, want empty metadata", meta)
+			}
+		})
+	}
+}
+
+func TestReadEXIFIgnoresInvalidOrientation(t *testing.T) {
+	order := binary.LittleEndian
+	var meta Metadata
+	readEXIF(buildEXIF(order, []exifEntry{shortEntry(tagOrientation, 9, order)}, nil), &meta)
+	if meta.Orientation != 0 {
+		t.Errorf("Orientation = %d, want 0", meta.Orientation)
+	}
+}
+
+func TestCaptureTime(t *testing.T) {
+	tests := []struct {
+		dateTime, offset, want string
+	}{
+		{"2024:05:01 13:45:10", "+02:00", "2024-05-01T13:45:10+02:00"},
+		{"2024:05:01 13:45:10", "", "2024-05-01T13:45:10"},
+		// An invalid offset is ignored rather than the date
+		{"2024:05:01 13:45:10", "bogus", "2024-05-01T13:45:10"},
+		{"0000:00:00 00:00:00", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := captureTime(tt.dateTime, tt.offset); got != tt.want {
+			t.Errorf("captureTime(%q, %q) = %q, want %q", tt.dateTime, tt.offset, got, tt.want)
+		}
+	}
+}
@@ -16,3 +16,89 @@ func StringSliceToJSON(slice []string) datatypes.JSON {
 	}
 	return datatypes.JSON(data)
 }
+
+// JSONToStringSlice converts datatypes.JSON holding a JSON array of strings to a slice.
+func JSONToStringSlice(data datatypes.JSON) []string {
+	var slice []string
+	if len(data) == 0 {
+		return slice
+	}
+	if err := json.Unmarshal(data, &slice); err != nil {
+		return nil
+	}
+	return slice
+}
+
+// RenditionsToJSON converts the renditions of each image, a map of rendition
+// name to URL, to datatypes.JSON. Nil maps are stored as null.
+func RenditionsToJSON(images []map[string]string) datatypes.JSON {
+	data, err := json.Marshal(images)
+	if err != nil {
+		return datatypes.JSON("[]")
+	}
+	return datatypes.JSON(data)
+}
+
+// JSONToRenditions converts datatypes.JSON holding a JSON array of rendition
+// maps to a slice.
+func JSONToRenditions(data datatypes.JSON) []map[string]string {
+	var images []map[string]string
+	if len(data) == 0 {
+		return images
+	}
+	if err := json.Unmarshal(data, &images); err != nil {
+		return nil
+	}
+	return images
+}
+
+// StringMapToJSON converts a map of strings to datatypes.JSON.
+func StringMapToJSON(m map[string]string) datatypes.JSON {
+	if m == nil {
+		return datatypes.JSON("{}")
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return datatypes.JSON("{}")
+	}
+	return datatypes.JSON(data)
+}
+
+// MergePatch applies an RFC 7396 JSON merge patch to the original document.
+func MergePatch(original, patch []byte) ([]byte, error) {
+	var patchValue interface{}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, err
+	}
+
+	var originalValue interface{}
+	if len(original) > 0 {
+		if err := json.Unmarshal(original, &originalValue); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(mergeValue(originalValue, patchValue))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+
+	return targetObject
+}
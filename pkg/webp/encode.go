@@ -0,0 +1,323 @@
+// pkg/webp/encode.go
+
+// Package webp encodes images as lossless WebP (VP8L).
+//
+// The encoder applies the subtract-green and predictor transforms, replaces
+// runs of repeated pixels by backward references and entropy codes the rest
+// with one set of Huffman codes. Files are larger than those of libwebp, which
+// searches far harder, but it needs no cgo and keeps the alpha channel.
+package webp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+)
+
+const (
+	maxDimension = 1 << 14
+
+	vp8lSignature = 0x2f
+
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+
+	// predictorBits is the log-2 tile size of the predictor transform
+	predictorBits = 9
+	// predictorMode is ClampAddSubtractFull, clamp(L + T - TL), for every tile
+	predictorMode = 12
+
+	numLiteralCodes  = 256
+	numLengthCodes   = 24
+	numDistanceCodes = 40
+
+	maxCodeLength           = 15
+	maxCodeLengthCodeLength = 7
+)
+
+// codeLengthCodeOrder is the order code length code lengths are written in.
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// Encode writes img to w as a lossless WebP image.
+func Encode(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > maxDimension || height > maxDimension {
+		return errors.New("webp: image dimensions must be between 1 and 16384")
+	}
+
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) || nrgba.Stride != 4*width {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(nrgba, nrgba.Rect, img, bounds.Min, draw.Src)
+	}
+
+	var bw bitWriter
+	bw.writeBits(vp8lSignature, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if nrgba.Opaque() {
+		bw.writeBits(0, 1)
+	} else {
+		bw.writeBits(1, 1)
+	}
+	bw.writeBits(0, 3) // version
+
+	// Transforms are undone by the decoder in reverse order
+	bw.writeBits(1, 1)
+	bw.writeBits(transformSubtractGreen, 2)
+	bw.writeBits(1, 1)
+	bw.writeBits(transformPredictor, 2)
+	bw.writeBits(predictorBits-2, 3)
+	writePredictorImage(&bw)
+	bw.writeBits(0, 1)
+
+	residuals := predict(subtractGreen(nrgba.Pix), width, height)
+	writeImage(&bw, residuals, width)
+
+	return writeRIFF(w, bw.bytes())
+}
+
+// subtractGreen returns a copy of the RGBA pixels with green subtracted from
+// red and blue.
+func subtractGreen(pix []byte) []byte {
+	out := make([]byte, len(pix))
+	for i := 0; i < len(pix); i += 4 {
+		g := pix[i+1]
+		out[i+0] = pix[i+0] - g
+		out[i+1] = g
+		out[i+2] = pix[i+2] - g
+		out[i+3] = pix[i+3]
+	}
+	return out
+}
+
+// predict replaces each pixel by its difference to the predicted value. As
+// the format requires, the first pixel is predicted as opaque black, the rest
+// of the first row from the left and the first column from the top.
+func predict(pix []byte, width, height int) []byte {
+	out := make([]byte, len(pix))
+	stride := 4 * width
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := y*stride + 4*x
+			for c := 0; c < 4; c++ {
+				var prediction byte
+				switch {
+				case x == 0 && y == 0:
+					if c == 3 {
+						prediction = 0xff
+					}
+				case y == 0:
+					prediction = pix[p-4+c]
+				case x == 0:
+					prediction = pix[p-stride+c]
+				default:
+					prediction = clampAddSubtractFull(pix[p-4+c], pix[p-stride+c], pix[p-stride-4+c])
+				}
+				out[p+c] = pix[p+c] - prediction
+			}
+		}
+	}
+	return out
+}
+
+func clampAddSubtractFull(l, t, tl byte) byte {
+	v := int(l) + int(t) - int(tl)
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return byte(v)
+}
+
+// writePredictorImage writes the sub-image selecting predictorMode for every
+// tile. All its pixels are equal, so each channel takes a one-symbol code and
+// the pixels themselves take no bits.
+func writePredictorImage(bw *bitWriter) {
+	bw.writeBits(0, 1)                 // no color cache
+	writeSimpleCode(bw, predictorMode) // green holds the mode
+	writeSimpleCode(bw, 0)             // red
+	writeSimpleCode(bw, 0)             // blue
+	writeSimpleCode(bw, 0)             // alpha
+	writeSimpleCode(bw, 0)             // distance
+}
+
+// writeImage entropy codes the main image. Runs of pixels repeating the
+// pixel to the left or above, typical of flat backgrounds once predicted, are
+// written as backward references.
+func writeImage(bw *bitWriter, pix []byte, width int) {
+	tokens := tokenize(pix, width)
+
+	green := make([]int, numLiteralCodes+numLengthCodes)
+	red := make([]int, numLiteralCodes)
+	blue := make([]int, numLiteralCodes)
+	alpha := make([]int, numLiteralCodes)
+	distance := make([]int, numDistanceCodes)
+	for _, t := range tokens {
+		if t.length == 0 {
+			red[pix[t.pos+0]]++
+			green[pix[t.pos+1]]++
+			blue[pix[t.pos+2]]++
+			alpha[pix[t.pos+3]]++
+			continue
+		}
+		lengthSymbol, _, _ := prefixEncode(t.length)
+		distanceSymbol, _, _ := prefixEncode(t.distanceCode)
+		green[numLiteralCodes+lengthSymbol]++
+		distance[distanceSymbol]++
+	}
+
+	bw.writeBits(0, 1) // no color cache
+	bw.writeBits(0, 1) // a single set of codes for the whole image
+
+	codes := [5]huffmanCode{
+		writeHuffmanCode(bw, green),
+		writeHuffmanCode(bw, red),
+		writeHuffmanCode(bw, blue),
+		writeHuffmanCode(bw, alpha),
+		writeHuffmanCode(bw, distance),
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].write(bw, int(pix[t.pos+1]))
+			codes[1].write(bw, int(pix[t.pos+0]))
+			codes[2].write(bw, int(pix[t.pos+2]))
+			codes[3].write(bw, int(pix[t.pos+3]))
+			continue
+		}
+		symbol, extraBits, extra := prefixEncode(t.length)
+		codes[0].write(bw, numLiteralCodes+symbol)
+		bw.writeBits(extra, extraBits)
+		symbol, extraBits, extra = prefixEncode(t.distanceCode)
+		codes[4].write(bw, symbol)
+		bw.writeBits(extra, extraBits)
+	}
+}
+
+const (
+	minCopyLength = 3
+	maxCopyLength = 4096
+
+	// Distance codes of the pixel above and to the left
+	distanceCodeAbove = 1
+	distanceCodeLeft  = 2
+)
+
+// token is a literal pixel at byte offset pos, or a copy of length pixels
+// from the neighbour given by distanceCode.
+type token struct {
+	pos          int
+	length       int
+	distanceCode int
+}
+
+func tokenize(pix []byte, width int) []token {
+	n := len(pix) / 4
+	tokens := make([]token, 0, n)
+	for i := 0; i < n; {
+		left := runLength(pix, i, 1)
+		above := runLength(pix, i, width)
+
+		switch {
+		case left >= minCopyLength && left >= above:
+			tokens = append(tokens, token{pos: 4 * i, length: left, distanceCode: distanceCodeLeft})
+			i += left
+		case above >= minCopyLength:
+			tokens = append(tokens, token{pos: 4 * i, length: above, distanceCode: distanceCodeAbove})
+			i += above
+		default:
+			tokens = append(tokens, token{pos: 4 * i})
+			i++
+		}
+	}
+	return tokens
+}
+
+// runLength counts the pixels from i on that equal the pixel distance before them.
+func runLength(pix []byte, i, distance int) int {
+	if i < distance {
+		return 0
+	}
+	n := len(pix) / 4
+	length := 0
+	for j := i; j < n && length < maxCopyLength; j++ {
+		p, q := 4*j, 4*(j-distance)
+		if pix[p] != pix[q] || pix[p+1] != pix[q+1] || pix[p+2] != pix[q+2] || pix[p+3] != pix[q+3] {
+			break
+		}
+		length++
+	}
+	return length
+}
+
+// prefixEncode splits a length or distance code into its prefix symbol and
+// extra bits.
+func prefixEncode(value int) (symbol int, extraBits uint, extra uint32) {
+	d := value - 1
+	if d < 4 {
+		return d, 0, 0
+	}
+	highest := 0
+	for d>>(highest+1) != 0 {
+		highest++
+	}
+	second := (d >> (highest - 1)) & 1
+	extraBits = uint(highest - 1)
+	return 2*highest + second, extraBits, uint32(d) & (1<<extraBits - 1)
+}
+
+func writeRIFF(w io.Writer, data []byte) error {
+	padding := len(data) & 1
+
+	var header [20]byte
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(12+len(data)+padding))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(len(data)))
+
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		if _, err := w.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bitWriter writes bits least significant first, as VP8L requires.
+type bitWriter struct {
+	buf   bytes.Buffer
+	bits  uint64
+	nBits uint
+}
+
+func (w *bitWriter) writeBits(value uint32, n uint) {
+	w.bits |= uint64(value) << w.nBits
+	w.nBits += n
+	for w.nBits >= 8 {
+		w.buf.WriteByte(byte(w.bits))
+		w.bits >>= 8
+		w.nBits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nBits > 0 {
+		w.buf.WriteByte(byte(w.bits))
+		w.bits, w.nBits = 0, 0
+	}
+	return w.buf.Bytes()
+}
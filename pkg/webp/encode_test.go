@@ -0,0 +1,173 @@
+// pkg/webp/encode_test.go
+
+package webp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	xwebp "golang.org/x/image/webp"
+)
+
+// testImages covers the paths of the encoder: single-symbol Huffman codes,
+// long runs turned into backward references, noise with full histograms,
+// transparency and sizes not multiple of the predictor tiles.
+func testImages() map[string]image.Image {
+	images := make(map[string]image.Image)
+
+	single := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	single.Set(0, 0, color.NRGBA{10, 20, 30, 255})
+	images["1x1"] = single
+
+	uniform := image.NewNRGBA(image.Rect(0, 0, 600, 3))
+	for i := 0; i < len(uniform.Pix); i += 4 {
+		copy(uniform.Pix[i:], []byte{200, 100, 50, 255})
+	}
+	images["uniform"] = uniform
+
+	gradient := image.NewNRGBA(image.Rect(0, 0, 517, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 517; x++ {
+			gradient.Set(x, y, color.NRGBA{uint8(x), uint8(y), uint8(x + y), 255})
+		}
+	}
+	images["gradient"] = gradient
+
+	rng := rand.New(rand.NewSource(1))
+	noise := image.NewNRGBA(image.Rect(0, 0, 97, 61))
+	rng.Read(noise.Pix)
+	images["noise with alpha"] = noise
+
+	stripes := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			stripes.Set(x, y, color.NRGBA{uint8(255 * ((x / 4) % 2)), 0, 0, uint8(255 * (y % 2))})
+		}
+	}
+	images["stripes"] = stripes
+
+	// Not an NRGBA image, with an offset origin
+	gray := image.NewGray(image.Rect(5, 7, 45, 37))
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(i * 7)
+	}
+	images["offset gray"] = gray
+
+	return images
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for name, img := range testImages() {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, img); err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := xwebp.Decode(&buf)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			bounds := img.Bounds()
+			if decoded.Bounds().Dx() != bounds.Dx() || decoded.Bounds().Dy() != bounds.Dy() {
+				t.Fatalf("decoded size %v, want %v", decoded.Bounds().Size(), bounds.Size())
+			}
+			for y := 0; y < bounds.Dy(); y++ {
+				for x := 0; x < bounds.Dx(); x++ {
+					want := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+					got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+					// Fully transparent pixels may have any color
+					if want.A == 0 && got.A == 0 {
+						continue
+					}
+					if got != want {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 3, 5))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if string(data[0:4]) != "RIFF" || string(data[8:16]) != "WEBPVP8L" {
+		t.Fatalf("header = %q", data[:16])
+	}
+	if len(data)%2 != 0 {
+		t.Errorf("file size %d is odd", len(data))
+	}
+	config, err := xwebp.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != 3 || config.Height != 5 {
+		t.Errorf("config size = %dx%d, want 3x5", config.Width, config.Height)
+	}
+}
+
+func TestEncodeRejectsInvalidDimensions(t *testing.T) {
+	for _, rect := range []image.Rectangle{
+		image.Rect(0, 0, 0, 10),
+		image.Rect(0, 0, maxDimension+1, 1),
+	} {
+		if err := Encode(&bytes.Buffer{}, image.NewGray(rect)); err == nil {
+			t.Errorf("Encode(%v) succeeded", rect)
+		}
+	}
+}
+
+func TestHuffmanLengthsRespectLimit(t *testing.T) {
+	// Fibonacci counts give the deepest unlimited trees
+	histogram := make([]int, 30)
+	a, b := 1, 1
+	for i := range histogram {
+		histogram[i] = a
+		a, b = b, a+b
+	}
+	lengths := huffmanLengths(histogram, maxCodeLength)
+
+	kraft := 0.0
+	for symbol, length := range lengths {
+		if length > maxCodeLength {
+			t.Errorf("symbol %d has length %d", symbol, length)
+		}
+		if length > 0 {
+			kraft += 1 / float64(uint(1)<<length)
+		}
+	}
+	if kraft > 1 {
+		t.Errorf("lengths are not a prefix code: Kraft sum %v", kraft)
+	}
+}
+
+func TestPrefixEncode(t *testing.T) {
+	tests := []struct {
+		value     int
+		symbol    int
+		extraBits uint
+		extra     uint32
+	}{
+		{1, 0, 0, 0},
+		{4, 3, 0, 0},
+		{5, 4, 1, 0},
+		{6, 4, 1, 1},
+		{7, 5, 1, 0},
+		{9, 6, 2, 0},
+		{4096, 23, 10, 1023},
+	}
+	for _, tt := range tests {
+		symbol, extraBits, extra := prefixEncode(tt.value)
+		if symbol != tt.symbol || extraBits != tt.extraBits || extra != tt.extra {
+			t.Errorf("prefixEncode(%d) = %d, %d, %d, want %d, %d, %d",
+				tt.value, symbol, extraBits, extra, tt.symbol, tt.extraBits, tt.extra)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// pkg/webp/huffman.go
+
+package webp
+
+// huffmanCode holds the canonical code of each symbol, bit-reversed so it can
+// be written least significant bit first.
+type huffmanCode struct {
+	lengths []uint8
+	codes   []uint16
+	// trivial codes have a single symbol, which takes no bits to write
+	trivial bool
+}
+
+func (h *huffmanCode) write(bw *bitWriter, symbol int) {
+	if h.trivial || h.lengths == nil {
+		return
+	}
+	bw.writeBits(uint32(h.codes[symbol]), uint(h.lengths[symbol]))
+}
+
+// writeSimpleCode writes a code with the single symbol, which must be below 256.
+func writeSimpleCode(bw *bitWriter, symbol int) {
+	bw.writeBits(1, 1) // simple code
+	bw.writeBits(0, 1) // one symbol
+	bw.writeBits(1, 1) // 8-bit symbol
+	bw.writeBits(uint32(symbol), 8)
+}
+
+// writeHuffmanCode writes the code built from the symbol histogram and
+// returns it for coding the symbols.
+func writeHuffmanCode(bw *bitWriter, histogram []int) huffmanCode {
+	var symbols []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	switch {
+	case len(symbols) == 0:
+		writeSimpleCode(bw, 0)
+		return huffmanCode{trivial: true}
+	case len(symbols) == 1 && symbols[0] < 256:
+		writeSimpleCode(bw, symbols[0])
+		return huffmanCode{trivial: true}
+	case len(symbols) == 2 && symbols[1] < 256:
+		bw.writeBits(1, 1) // simple code
+		bw.writeBits(1, 1) // two symbols
+		bw.writeBits(1, 1) // 8-bit first symbol
+		bw.writeBits(uint32(symbols[0]), 8)
+		bw.writeBits(uint32(symbols[1]), 8)
+
+		code := huffmanCode{
+			lengths: make([]uint8, len(histogram)),
+			codes:   make([]uint16, len(histogram)),
+		}
+		code.lengths[symbols[0]], code.codes[symbols[0]] = 1, 0
+		code.lengths[symbols[1]], code.codes[symbols[1]] = 1, 1
+		return code
+	}
+
+	code := newHuffmanCode(histogram, maxCodeLength)
+	writeCodeLengths(bw, code.lengths)
+	return code
+}
+
+// writeCodeLengths writes the code lengths of a normal code, themselves
+// Huffman coded with the code length code.
+func writeCodeLengths(bw *bitWriter, lengths []uint8) {
+	histogram := make([]int, len(codeLengthCodeOrder))
+	for _, length := range lengths {
+		histogram[length]++
+	}
+	lengthCode := newHuffmanCode(histogram, maxCodeLengthCodeLength)
+
+	numCodes := 4
+	for i, symbol := range codeLengthCodeOrder {
+		if lengthCode.lengths[symbol] != 0 && i+1 > numCodes {
+			numCodes = i + 1
+		}
+	}
+
+	bw.writeBits(0, 1) // normal code
+	bw.writeBits(uint32(numCodes-4), 4)
+	for _, symbol := range codeLengthCodeOrder[:numCodes] {
+		bw.writeBits(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	bw.writeBits(0, 1) // code lengths for the whole alphabet follow
+
+	for _, length := range lengths {
+		lengthCode.write(bw, int(length))
+	}
+}
+
+// newHuffmanCode builds a canonical code whose lengths do not exceed limit.
+func newHuffmanCode(histogram []int, limit int) huffmanCode {
+	code := huffmanCode{
+		lengths: huffmanLengths(histogram, limit),
+		codes:   make([]uint16, len(histogram)),
+	}
+
+	var lengthCounts [maxCodeLength + 2]int
+	used := 0
+	for _, length := range code.lengths {
+		if length > 0 {
+			lengthCounts[length]++
+			used++
+		}
+	}
+	code.trivial = used == 1
+
+	var nextCode [maxCodeLength + 2]int
+	for length, c := 1, 0; length <= maxCodeLength; length++ {
+		c = (c + lengthCounts[length-1]) << 1
+		nextCode[length] = c
+	}
+	for symbol, length := range code.lengths {
+		if length == 0 {
+			continue
+		}
+		code.codes[symbol] = reverseBits(uint16(nextCode[length]), length)
+		nextCode[length]++
+	}
+	return code
+}
+
+func reverseBits(code uint16, length uint8) uint16 {
+	var reversed uint16
+	for i := uint8(0); i < length; i++ {
+		reversed = reversed<<1 | code&1
+		code >>= 1
+	}
+	return reversed
+}
+
+// huffmanLengths computes Huffman code lengths for the histogram, flattening
+// the counts until no length exceeds limit. A single used symbol gets length 1.
+func huffmanLengths(histogram []int, limit int) []uint8 {
+	counts := append([]int(nil), histogram...)
+	for {
+		lengths, maxLength := buildLengths(counts)
+		if maxLength <= limit {
+			return lengths
+		}
+		for i, count := range counts {
+			if count > 0 {
+				counts[i] = (count + 1) / 2
+			}
+		}
+	}
+}
+
+type huffmanNode struct {
+	weight      int
+	symbol      int
+	left, right int
+}
+
+func buildLengths(counts []int) ([]uint8, int) {
+	lengths := make([]uint8, len(counts))
+
+	var nodes []huffmanNode
+	var active []int
+	for symbol, count := range counts {
+		if count > 0 {
+			nodes = append(nodes, huffmanNode{weight: count, symbol: symbol, left: -1, right: -1})
+			active = append(active, len(nodes)-1)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return lengths, 0
+	case 1:
+		lengths[nodes[0].symbol] = 1
+		return lengths, 1
+	}
+
+	// Repeatedly merge the two lightest nodes
+	for len(active) > 1 {
+		first := lightest(nodes, active, -1)
+		second := lightest(nodes, active, first)
+		nodes = append(nodes, huffmanNode{
+			weight: nodes[active[first]].weight + nodes[active[second]].weight,
+			symbol: -1,
+			left:   active[first],
+			right:  active[second],
+		})
+
+		remaining := active[:0]
+		for i, node := range active {
+			if i != first && i != second {
+				remaining = append(remaining, node)
+			}
+		}
+		active = append(remaining, len(nodes)-1)
+	}
+
+	maxLength := 0
+	var walk func(node, depth int)
+	walk = func(node, depth int) {
+		if nodes[node].symbol >= 0 {
+			lengths[nodes[node].symbol] = uint8(depth)
+			if depth > maxLength {
+				maxLength = depth
+			}
+			return
+		}
+		walk(nodes[node].left, depth+1)
+		walk(nodes[node].right, depth+1)
+	}
+	walk(active[0], 0)
+
+	return lengths, maxLength
+}
+
+// lightest returns the index in active of the node with the smallest weight,
+// skipping the index skip.
+func lightest(nodes []huffmanNode, active []int, skip int) int {
+	best := -1
+	for i, node := range active {
+		if i == skip {
+			continue
+		}
+		if best < 0 || nodes[node].weight < nodes[active[best]].weight {
+			best = i
+		}
+	}
+	return best
+}